@@ -13,7 +13,13 @@ var (
 	ErrTopLevelNotObjArray = fmt.Errorf("top-level JSON must be object or array")
 )
 
+// Canonicalize returns the RFC 8785 (JCS) form of a JSON object or array.
+// Input beyond DefaultLimits is rejected; see CanonicalizeWithLimits.
 func Canonicalize(input []byte) ([]byte, error) {
+	return CanonicalizeWithLimits(input, DefaultLimits)
+}
+
+func canonicalize(input []byte) ([]byte, error) {
 	if len(input) == 0 {
 		return nil, ErrEmptyInput
 	}
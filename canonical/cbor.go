@@ -0,0 +1,256 @@
+package canonical
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"unicode/utf8"
+
+	"github.com/x448/float16"
+)
+
+var (
+	ErrInvalidCBOR          = errors.New("invalid cbor")
+	ErrCBORIndefiniteLength = errors.New("cbor indefinite-length item")
+	ErrCBORDuplicateKey     = errors.New("cbor duplicate map key")
+)
+
+// CanonicalizeCBOR re-encodes a single CBOR data item in RFC 8949 core
+// deterministic encoding (section 4.2.1): shortest arguments, shortest
+// floats that preserve the value, map keys sorted by their encoded bytes.
+// Indefinite-length items, duplicate map keys, trailing data and invalid
+// UTF-8 in text strings are rejected. Input beyond DefaultLimits is
+// rejected; MaxStringLength applies to byte and text strings.
+func CanonicalizeCBOR(input []byte) ([]byte, error) {
+	return CanonicalizeCBORWithLimits(input, DefaultLimits)
+}
+
+// CanonicalizeCBORWithLimits is CanonicalizeCBOR with explicit limits.
+func CanonicalizeCBORWithLimits(input []byte, l Limits) ([]byte, error) {
+	if len(input) == 0 {
+		return nil, ErrEmptyInput
+	}
+	if l.MaxSize > 0 && int64(len(input)) > l.MaxSize {
+		return nil, &LimitError{Err: ErrTooLarge, Limit: l.MaxSize, Offset: l.MaxSize}
+	}
+	d := cborDecoder{b: input, l: l}
+	var out bytes.Buffer
+	if err := d.item(&out, 0); err != nil {
+		return nil, err
+	}
+	if d.i != len(input) {
+		return nil, d.fail(ErrInvalidCBOR, "trailing data")
+	}
+	return out.Bytes(), nil
+}
+
+const (
+	cborUint = iota
+	cborNegInt
+	cborBytes
+	cborText
+	cborArray
+	cborMap
+	cborTag
+	cborSimple
+)
+
+type cborDecoder struct {
+	b []byte
+	i int
+	l Limits
+}
+
+func (d *cborDecoder) fail(err error, detail string) error {
+	return fmt.Errorf("%w: %s at offset %d", err, detail, d.i)
+}
+
+// head reads an initial byte and its argument.
+func (d *cborDecoder) head() (major byte, info byte, arg uint64, err error) {
+	if d.i >= len(d.b) {
+		return 0, 0, 0, d.fail(ErrInvalidCBOR, "unexpected end of input")
+	}
+	ib := d.b[d.i]
+	major, info = ib>>5, ib&0x1f
+	d.i++
+
+	var n int
+	switch {
+	case info < 24:
+		return major, info, uint64(info), nil
+	case info <= 27:
+		n = 1 << (info - 24)
+	case info == 31:
+		if major == cborUint || major == cborNegInt || major == cborTag {
+			return 0, 0, 0, d.fail(ErrInvalidCBOR, "invalid additional information 31")
+		}
+		return 0, 0, 0, d.fail(ErrCBORIndefiniteLength, "indefinite length or break")
+	default:
+		return 0, 0, 0, d.fail(ErrInvalidCBOR, fmt.Sprintf("reserved additional information %d", info))
+	}
+	if len(d.b)-d.i < n {
+		return 0, 0, 0, d.fail(ErrInvalidCBOR, "unexpected end of input")
+	}
+	for _, c := range d.b[d.i : d.i+n] {
+		arg = arg<<8 | uint64(c)
+	}
+	d.i += n
+	return major, info, arg, nil
+}
+
+// item canonicalizes one data item into out.
+func (d *cborDecoder) item(out *bytes.Buffer, depth int) error {
+	start := d.i
+	major, info, arg, err := d.head()
+	if err != nil {
+		return err
+	}
+
+	switch major {
+	case cborUint, cborNegInt:
+		writeCBORHead(out, major, arg)
+
+	case cborBytes, cborText:
+		if d.l.MaxStringLength > 0 && arg > uint64(d.l.MaxStringLength) {
+			return &LimitError{Err: ErrStringTooLong, Limit: int64(d.l.MaxStringLength), Offset: int64(start)}
+		}
+		if arg > uint64(len(d.b)-d.i) {
+			return d.fail(ErrInvalidCBOR, "unexpected end of input")
+		}
+		s := d.b[d.i : d.i+int(arg)]
+		if major == cborText && !utf8.Valid(s) {
+			return d.fail(ErrInvalidCBOR, "invalid UTF-8 in text string")
+		}
+		d.i += int(arg)
+		writeCBORHead(out, major, arg)
+		out.Write(s)
+
+	case cborArray:
+		if err := d.enter(depth, start); err != nil {
+			return err
+		}
+		if arg > uint64(len(d.b)-d.i) {
+			return d.fail(ErrInvalidCBOR, "array longer than input")
+		}
+		writeCBORHead(out, major, arg)
+		for n := uint64(0); n < arg; n++ {
+			if err := d.item(out, depth+1); err != nil {
+				return err
+			}
+		}
+
+	case cborMap:
+		if err := d.enter(depth, start); err != nil {
+			return err
+		}
+		if d.l.MaxKeys > 0 && arg > uint64(d.l.MaxKeys) {
+			return &LimitError{Err: ErrTooManyKeys, Limit: int64(d.l.MaxKeys), Offset: int64(start)}
+		}
+		if arg > uint64(len(d.b)-d.i)/2 {
+			return d.fail(ErrInvalidCBOR, "map longer than input")
+		}
+		type pair struct{ k, v []byte }
+		pairs := make([]pair, 0, arg)
+		seen := make(map[string]struct{}, arg)
+		for n := uint64(0); n < arg; n++ {
+			var k, v bytes.Buffer
+			keyAt := d.i
+			if err := d.item(&k, depth+1); err != nil {
+				return err
+			}
+			if _, dup := seen[k.String()]; dup {
+				d.i = keyAt
+				return d.fail(ErrCBORDuplicateKey, "duplicate key")
+			}
+			seen[k.String()] = struct{}{}
+			if err := d.item(&v, depth+1); err != nil {
+				return err
+			}
+			pairs = append(pairs, pair{k.Bytes(), v.Bytes()})
+		}
+		// RFC 8949 section 4.2.1: bytewise lexicographic order of the
+		// deterministic encodings of the keys.
+		slices.SortFunc(pairs, func(a, b pair) int { return bytes.Compare(a.k, b.k) })
+		writeCBORHead(out, major, arg)
+		for _, p := range pairs {
+			out.Write(p.k)
+			out.Write(p.v)
+		}
+
+	case cborTag:
+		if err := d.enter(depth, start); err != nil {
+			return err
+		}
+		writeCBORHead(out, major, arg)
+		return d.item(out, depth+1)
+
+	case cborSimple:
+		switch info {
+		case 24:
+			if arg < 32 {
+				return d.fail(ErrInvalidCBOR, "simple value below 32 in two bytes")
+			}
+			out.Write([]byte{0xf8, byte(arg)})
+		case 25:
+			writeCBORFloat(out, float64(float16.Frombits(uint16(arg)).Float32()))
+		case 26:
+			writeCBORFloat(out, float64(math.Float32frombits(uint32(arg))))
+		case 27:
+			writeCBORFloat(out, math.Float64frombits(arg))
+		default:
+			out.WriteByte(0xe0 | info)
+		}
+	}
+	return nil
+}
+
+func (d *cborDecoder) enter(depth, start int) error {
+	if d.l.MaxDepth > 0 && depth >= d.l.MaxDepth {
+		return &LimitError{Err: ErrTooDeep, Limit: int64(d.l.MaxDepth), Offset: int64(start)}
+	}
+	return nil
+}
+
+// writeCBORHead writes an initial byte with the shortest argument encoding.
+func writeCBORHead(out *bytes.Buffer, major byte, arg uint64) {
+	m := major << 5
+	switch {
+	case arg < 24:
+		out.WriteByte(m | byte(arg))
+	case arg <= math.MaxUint8:
+		out.Write([]byte{m | 24, byte(arg)})
+	case arg <= math.MaxUint16:
+		out.WriteByte(m | 25)
+		out.Write(binary.BigEndian.AppendUint16(nil, uint16(arg)))
+	case arg <= math.MaxUint32:
+		out.WriteByte(m | 26)
+		out.Write(binary.BigEndian.AppendUint32(nil, uint32(arg)))
+	default:
+		out.WriteByte(m | 27)
+		out.Write(binary.BigEndian.AppendUint64(nil, arg))
+	}
+}
+
+// writeCBORFloat writes f in the shortest of half, single and double
+// precision that represents it exactly; every NaN becomes 0xf97e00.
+func writeCBORFloat(out *bytes.Buffer, f float64) {
+	if math.IsNaN(f) {
+		out.Write([]byte{0xf9, 0x7e, 0x00})
+		return
+	}
+	if f32 := float32(f); float64(f32) == f {
+		if h := float16.Fromfloat32(f32); math.Float32bits(h.Float32()) == math.Float32bits(f32) {
+			out.WriteByte(0xf9)
+			out.Write(binary.BigEndian.AppendUint16(nil, h.Bits()))
+			return
+		}
+		out.WriteByte(0xfa)
+		out.Write(binary.BigEndian.AppendUint32(nil, math.Float32bits(f32)))
+		return
+	}
+	out.WriteByte(0xfb)
+	out.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(f)))
+}
@@ -0,0 +1,99 @@
+package canonical
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestCanonicalizeCBOR_Deterministic(t *testing.T) {
+	cases := []struct {
+		name, in, want string
+	}{
+		// {"b": 1, "a": 2} -> {"a": 2, "b": 1}
+		{"map key order", "a2616201616102", "a2616102616201"},
+		// 1 encoded with an 8-byte argument
+		{"shortest int", "1b0000000000000001", "01"},
+		// text string "a" with a 1-byte length argument
+		{"shortest length", "780161", "6161"},
+		// 1.5 as double -> half
+		{"float to half", "fb3ff8000000000000", "f93e00"},
+		// 100000.0 as double -> single
+		{"float to single", "fb40f86a0000000000", "fa47c35000"},
+		// 1.1 stays double
+		{"float stays double", "fb3ff199999999999a", "fb3ff199999999999a"},
+		// NaN as single -> canonical half NaN
+		{"nan", "fa7fc00000", "f97e00"},
+		// tag 1(1700000000) with a long argument
+		{"tag", "c11a6553f100", "c11a6553f100"},
+		// {10: 0, -1: 0, "z": 0, [1]: 0}: keys sorted by encoded bytes
+		{"mixed keys", "a4617a002000810100" + "0a00", "a40a002000617a00810100"},
+	}
+	for _, c := range cases {
+		got, err := CanonicalizeCBOR(mustHex(t, c.in))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", c.name, err)
+		}
+		if hex.EncodeToString(got) != c.want {
+			t.Fatalf("%s: want %s, got %x", c.name, c.want, got)
+		}
+	}
+}
+
+func TestCanonicalizeCBOR_Rejected(t *testing.T) {
+	cases := []struct {
+		name, in string
+		want     error
+	}{
+		{"empty", "", ErrEmptyInput},
+		{"indefinite array", "9f01ff", ErrCBORIndefiniteLength},
+		{"indefinite text", "7f616161ff", ErrCBORIndefiniteLength},
+		{"duplicate key", "a2616101616102", ErrCBORDuplicateKey},
+		// 1 and 1 with a long argument are the same key
+		{"duplicate key after re-encoding", "a20100180100", ErrCBORDuplicateKey},
+		{"trailing data", "0101", ErrInvalidCBOR},
+		{"truncated", "62616", ErrInvalidCBOR},
+		{"invalid utf-8", "61ff", ErrInvalidCBOR},
+		{"reserved info", "1c", ErrInvalidCBOR},
+	}
+	for _, c := range cases {
+		in, _ := hex.DecodeString(c.in)
+		_, err := CanonicalizeCBOR(in)
+		if !errors.Is(err, c.want) {
+			t.Fatalf("%s: want %v, got %v", c.name, c.want, err)
+		}
+	}
+}
+
+func TestCanonicalizeCBOR_Idempotent(t *testing.T) {
+	in := mustHex(t, "a3616302616182fb3ff8000000000000f5616280")
+	once, err := CanonicalizeCBOR(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	twice, err := CanonicalizeCBOR(once)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(once, twice) {
+		t.Fatalf("not idempotent: %x vs %x", once, twice)
+	}
+}
+
+func TestCanonicalizeCBOR_DepthLimit(t *testing.T) {
+	in := bytes.Repeat([]byte{0x81}, 200)
+	in = append(in, 0x01)
+	if _, err := CanonicalizeCBOR(in); !errors.Is(err, ErrTooDeep) {
+		t.Fatalf("want ErrTooDeep, got %v", err)
+	}
+}
@@ -0,0 +1,298 @@
+package canonical
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"unicode/utf16"
+
+	jcs "github.com/gowebpki/jcs"
+)
+
+// Difference kinds reported by Diff. Added, Removed and Changed alter the
+// canonical form (and so the payload hash); the others are differences in the
+// input text that canonicalization hides or rejects.
+const (
+	DiffAdded   = "added"
+	DiffRemoved = "removed"
+	DiffChanged = "changed"
+
+	// DiffNumberFormat: same number written differently (1.0 vs 1, 1e2 vs 100).
+	DiffNumberFormat = "number-format"
+	// DiffStringEscape: same string escaped differently ("\u00e9" vs "é").
+	DiffStringEscape = "string-escape"
+	// DiffDuplicateKey: a member name appears more than once on one side;
+	// Canonicalize rejects such input, and other parsers may keep either value.
+	DiffDuplicateKey = "duplicate-key"
+)
+
+// Difference is one difference found by Diff at the RFC 6901 JSON pointer
+// Pointer. A and B hold the canonical value of each side (the literal as
+// written for number-format and string-escape, the number of occurrences for
+// duplicate-key); they are empty where a side has no value.
+type Difference struct {
+	Pointer string `json:"pointer"`
+	Kind    string `json:"kind"`
+	A       string `json:"a,omitempty"`
+	B       string `json:"b,omitempty"`
+}
+
+// Canonical reports whether the difference changes the canonical form.
+func (d Difference) Canonical() bool {
+	return d.Kind == DiffAdded || d.Kind == DiffRemoved || d.Kind == DiffChanged
+}
+
+// Diff compares two JSON documents value by value, in JCS terms. Object
+// members are matched by name (order and whitespace never matter) and array
+// elements by position. Differences are returned in document order, using
+// the last value of a duplicated member name like encoding/json does.
+func Diff(a, b []byte) ([]Difference, error) {
+	na, err := parseDiffTree(a)
+	if err != nil {
+		return nil, fmt.Errorf("a: %w", err)
+	}
+	nb, err := parseDiffTree(b)
+	if err != nil {
+		return nil, fmt.Errorf("b: %w", err)
+	}
+	var diffs []Difference
+	diffNodes(&diffs, "", na, nb)
+	return diffs, nil
+}
+
+type diffNode struct {
+	kind      byte   // '{', '[', '"', '0' (number), 't' (true/false), 'n'
+	raw       string // scalar literal as written
+	canonical string // scalar in JCS form
+	names     []string
+	members   map[string]*diffNode
+	count     map[string]int
+	elems     []*diffNode
+}
+
+func parseDiffTree(input []byte) (*diffNode, error) {
+	if len(input) == 0 {
+		return nil, ErrEmptyInput
+	}
+	if !json.Valid(input) {
+		return nil, ErrInvalidJSON
+	}
+	p := diffParser{input: input, dec: json.NewDecoder(bytes.NewReader(input))}
+	p.dec.UseNumber()
+	tok, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	return p.value(tok)
+}
+
+type diffParser struct {
+	input []byte
+	dec   *json.Decoder
+	raw   []byte // literal of the last token
+}
+
+func (p *diffParser) next() (json.Token, error) {
+	start := p.dec.InputOffset()
+	tok, err := p.dec.Token()
+	if err != nil {
+		return nil, invalidJSON(err)
+	}
+	p.raw = bytes.TrimLeft(p.input[start:p.dec.InputOffset()], " \t\r\n,:")
+	return tok, nil
+}
+
+func (p *diffParser) value(tok json.Token) (*diffNode, error) {
+	switch v := tok.(type) {
+	case json.Delim:
+		if v == '{' {
+			return p.object()
+		}
+		return p.array()
+	case string:
+		var buf bytes.Buffer
+		w := bufio.NewWriter(&buf)
+		writeString(w, v)
+		w.Flush()
+		return &diffNode{kind: '"', raw: string(p.raw), canonical: buf.String()}, nil
+	case json.Number:
+		n := &diffNode{kind: '0', raw: string(v), canonical: string(v)}
+		if f, err := strconv.ParseFloat(string(v), 64); err == nil {
+			if s, err := jcs.NumberToJSON(f); err == nil {
+				n.canonical = s
+			}
+		}
+		return n, nil
+	case bool:
+		s := strconv.FormatBool(v)
+		return &diffNode{kind: 't', raw: s, canonical: s}, nil
+	}
+	return &diffNode{kind: 'n', raw: "null", canonical: "null"}, nil
+}
+
+func (p *diffParser) object() (*diffNode, error) {
+	n := &diffNode{kind: '{', members: map[string]*diffNode{}, count: map[string]int{}}
+	for {
+		tok, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		if tok == json.Delim('}') {
+			return n, nil
+		}
+		name := tok.(string)
+		if tok, err = p.next(); err != nil {
+			return nil, err
+		}
+		v, err := p.value(tok)
+		if err != nil {
+			return nil, err
+		}
+		if n.count[name] == 0 {
+			n.names = append(n.names, name)
+		}
+		n.count[name]++
+		n.members[name] = v
+	}
+}
+
+func (p *diffParser) array() (*diffNode, error) {
+	n := &diffNode{kind: '['}
+	for {
+		tok, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		if tok == json.Delim(']') {
+			return n, nil
+		}
+		v, err := p.value(tok)
+		if err != nil {
+			return nil, err
+		}
+		n.elems = append(n.elems, v)
+	}
+}
+
+// canonicalText renders a node in JCS form, for added, removed and changed
+// values.
+func (n *diffNode) canonicalText() string {
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	n.writeCanonical(w)
+	w.Flush()
+	return buf.String()
+}
+
+func (n *diffNode) writeCanonical(w *bufio.Writer) {
+	switch n.kind {
+	case '{':
+		names := slices.Clone(n.names)
+		slices.SortStableFunc(names, func(a, b string) int {
+			ka, kb := utf16.Encode([]rune(a)), utf16.Encode([]rune(b))
+			switch {
+			case lessUTF16(ka, kb):
+				return -1
+			case lessUTF16(kb, ka):
+				return 1
+			}
+			return 0
+		})
+		w.WriteByte('{')
+		for i, name := range names {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			writeString(w, name)
+			w.WriteByte(':')
+			n.members[name].writeCanonical(w)
+		}
+		w.WriteByte('}')
+	case '[':
+		w.WriteByte('[')
+		for i, e := range n.elems {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			e.writeCanonical(w)
+		}
+		w.WriteByte(']')
+	default:
+		w.WriteString(n.canonical)
+	}
+}
+
+func diffNodes(diffs *[]Difference, ptr string, a, b *diffNode) {
+	if a.kind != b.kind || (a.kind != '{' && a.kind != '[' && a.canonical != b.canonical) {
+		*diffs = append(*diffs, Difference{Pointer: ptr, Kind: DiffChanged, A: a.canonicalText(), B: b.canonicalText()})
+		return
+	}
+
+	switch a.kind {
+	case '{':
+		names := slices.Clone(a.names)
+		for _, name := range b.names {
+			if _, ok := a.members[name]; !ok {
+				names = append(names, name)
+			}
+		}
+		for _, name := range names {
+			child := ptr + "/" + EscapePointerToken(name)
+			if a.count[name] > 1 || b.count[name] > 1 {
+				*diffs = append(*diffs, Difference{Pointer: child, Kind: DiffDuplicateKey, A: countText(a.count[name]), B: countText(b.count[name])})
+			}
+			av, aok := a.members[name]
+			bv, bok := b.members[name]
+			switch {
+			case !bok:
+				*diffs = append(*diffs, Difference{Pointer: child, Kind: DiffRemoved, A: av.canonicalText()})
+			case !aok:
+				*diffs = append(*diffs, Difference{Pointer: child, Kind: DiffAdded, B: bv.canonicalText()})
+			default:
+				diffNodes(diffs, child, av, bv)
+			}
+		}
+	case '[':
+		for i := 0; i < len(a.elems) || i < len(b.elems); i++ {
+			child := ptr + "/" + strconv.Itoa(i)
+			switch {
+			case i >= len(b.elems):
+				*diffs = append(*diffs, Difference{Pointer: child, Kind: DiffRemoved, A: a.elems[i].canonicalText()})
+			case i >= len(a.elems):
+				*diffs = append(*diffs, Difference{Pointer: child, Kind: DiffAdded, B: b.elems[i].canonicalText()})
+			default:
+				diffNodes(diffs, child, a.elems[i], b.elems[i])
+			}
+		}
+	case '0':
+		if a.raw != b.raw {
+			*diffs = append(*diffs, Difference{Pointer: ptr, Kind: DiffNumberFormat, A: a.raw, B: b.raw})
+		}
+	case '"':
+		if a.raw != b.raw {
+			*diffs = append(*diffs, Difference{Pointer: ptr, Kind: DiffStringEscape, A: a.raw, B: b.raw})
+		}
+	}
+}
+
+func countText(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+// invalidJSON maps decoder errors to ErrInvalidJSON, keeping read errors
+// from the underlying reader intact.
+func invalidJSON(err error) error {
+	var syn *json.SyntaxError
+	if err == io.EOF || errors.Is(err, io.ErrUnexpectedEOF) || errors.As(err, &syn) {
+		return ErrInvalidJSON
+	}
+	return err
+}
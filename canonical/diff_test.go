@@ -0,0 +1,65 @@
+package canonical
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDiff_Differences(t *testing.T) {
+	a := []byte(`{"id":1,"price":1.50,"name":"caf\u00e9","tags":["x","y"],"meta":{"a/b":true,"old":1},"role":"user","role":"admin"}`)
+	b := []byte(`{
+  "name": "café",
+  "price": 1.5,
+  "id": 1,
+  "tags": ["x"],
+  "meta": {"a/b": false, "new": {"z": 1, "a": [1e0]}},
+  "role": "admin"
+}`)
+	got, err := Diff(a, b)
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	want := []Difference{
+		{Pointer: "/price", Kind: DiffNumberFormat, A: "1.50", B: "1.5"},
+		{Pointer: "/name", Kind: DiffStringEscape, A: `"caf\u00e9"`, B: `"café"`},
+		{Pointer: "/tags/1", Kind: DiffRemoved, A: `"y"`},
+		{Pointer: "/meta/a~1b", Kind: DiffChanged, A: "true", B: "false"},
+		{Pointer: "/meta/old", Kind: DiffRemoved, A: "1"},
+		{Pointer: "/meta/new", Kind: DiffAdded, B: `{"a":[1],"z":1}`},
+		{Pointer: "/role", Kind: DiffDuplicateKey, A: "2", B: "1"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got  %+v\nwant %+v", got, want)
+	}
+}
+
+func TestDiff_SameCanonicalForm(t *testing.T) {
+	got, err := Diff([]byte(`{"b":[1,2],"a":"x"}`), []byte(` { "a" : "x", "b" : [ 1, 2 ] } `))
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("want no differences, got %+v", got)
+	}
+}
+
+func TestDiff_TypeChange(t *testing.T) {
+	got, err := Diff([]byte(`{"v":"1"}`), []byte(`{"v":1}`))
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	want := []Difference{{Pointer: "/v", Kind: DiffChanged, A: `"1"`, B: "1"}}
+	if !reflect.DeepEqual(got, want) || !got[0].Canonical() {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestDiff_InvalidJSONRejected(t *testing.T) {
+	if _, err := Diff([]byte(`{}`), []byte(`{"a":`)); !errors.Is(err, ErrInvalidJSON) {
+		t.Fatalf("want ErrInvalidJSON, got %v", err)
+	}
+	if _, err := Diff(nil, []byte(`{}`)); !errors.Is(err, ErrEmptyInput) {
+		t.Fatalf("want ErrEmptyInput, got %v", err)
+	}
+}
@@ -0,0 +1,259 @@
+package canonical
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+var ErrNotIJSON = errors.New("not I-JSON")
+
+// maxSafeInteger is 2^53-1, the largest integer every I-JSON reader can
+// represent exactly (RFC 7493 section 2.2).
+const maxSafeInteger = 1<<53 - 1
+
+// IJSONError reports where input violates RFC 7493. Pointer is the RFC 6901
+// JSON pointer of the offending value ("" for the document itself).
+type IJSONError struct {
+	Pointer string
+	Reason  string
+}
+
+func (e *IJSONError) Error() string {
+	return fmt.Sprintf("not I-JSON: %s at %q", e.Reason, e.Pointer)
+}
+
+func (e *IJSONError) Unwrap() error { return ErrNotIJSON }
+
+// CanonicalizeStrict is Canonicalize for I-JSON (RFC 7493) input only: it
+// rejects duplicate member names, strings with surrogates or noncharacters,
+// invalid UTF-8, and numbers that do not survive a round trip through an
+// IEEE 754 double (integers beyond 2^53-1, overflow), so that every reader
+// sees the same data under the same hash.
+func CanonicalizeStrict(input []byte) ([]byte, error) {
+	if len(input) == 0 {
+		return nil, ErrEmptyInput
+	}
+	if err := CheckLimits(input, DefaultLimits); err != nil {
+		return nil, err
+	}
+	if !json.Valid(input) {
+		return nil, ErrInvalidJSON
+	}
+	if err := ValidateIJSON(input); err != nil {
+		return nil, err
+	}
+	return canonicalize(input)
+}
+
+// ValidateIJSON checks syntactically valid JSON against RFC 7493. Errors are
+// *IJSONError and match ErrNotIJSON.
+func ValidateIJSON(input []byte) error {
+	if !json.Valid(input) {
+		return ErrInvalidJSON
+	}
+	s := ijsonScanner{b: input}
+	s.skipWS()
+	return s.value("")
+}
+
+// ijsonScanner walks input that json.Valid has accepted, so it only has to
+// look for I-JSON violations, not syntax errors.
+type ijsonScanner struct {
+	b []byte
+	i int
+}
+
+func (s *ijsonScanner) skipWS() {
+	for s.i < len(s.b) {
+		switch s.b[s.i] {
+		case ' ', '\t', '\n', '\r':
+			s.i++
+		default:
+			return
+		}
+	}
+}
+
+func (s *ijsonScanner) value(ptr string) error {
+	switch c := s.b[s.i]; {
+	case c == '{':
+		return s.object(ptr)
+	case c == '[':
+		return s.array(ptr)
+	case c == '"':
+		_, err := s.str(ptr)
+		return err
+	case c == 't':
+		s.i += len("true")
+	case c == 'f':
+		s.i += len("false")
+	case c == 'n':
+		s.i += len("null")
+	default:
+		return s.number(ptr)
+	}
+	return nil
+}
+
+func (s *ijsonScanner) object(ptr string) error {
+	s.i++ // '{'
+	seen := map[string]struct{}{}
+	for {
+		s.skipWS()
+		if s.b[s.i] == '}' {
+			s.i++
+			return nil
+		}
+		if s.b[s.i] == ',' {
+			s.i++
+			s.skipWS()
+		}
+		name, err := s.str(ptr)
+		if err != nil {
+			return err
+		}
+		child := ptr + "/" + EscapePointerToken(name)
+		if _, dup := seen[name]; dup {
+			return &IJSONError{Pointer: child, Reason: fmt.Sprintf("duplicate member name %q", name)}
+		}
+		seen[name] = struct{}{}
+		s.skipWS()
+		s.i++ // ':'
+		s.skipWS()
+		if err := s.value(child); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *ijsonScanner) array(ptr string) error {
+	s.i++ // '['
+	for n := 0; ; n++ {
+		s.skipWS()
+		if s.b[s.i] == ']' {
+			s.i++
+			return nil
+		}
+		if s.b[s.i] == ',' {
+			s.i++
+			s.skipWS()
+		}
+		if err := s.value(ptr + "/" + strconv.Itoa(n)); err != nil {
+			return err
+		}
+	}
+}
+
+// str decodes the string starting at s.i and checks its code points.
+func (s *ijsonScanner) str(ptr string) (string, error) {
+	s.i++ // '"'
+	var sb strings.Builder
+	for {
+		c := s.b[s.i]
+		switch {
+		case c == '"':
+			s.i++
+			return sb.String(), nil
+		case c == '\\':
+			r, err := s.escape(ptr)
+			if err != nil {
+				return "", err
+			}
+			if isNoncharacter(r) {
+				return "", &IJSONError{Pointer: ptr, Reason: fmt.Sprintf("noncharacter U+%04X", r)}
+			}
+			sb.WriteRune(r)
+		case c < utf8.RuneSelf:
+			sb.WriteByte(c)
+			s.i++
+		default:
+			r, size := utf8.DecodeRune(s.b[s.i:])
+			if r == utf8.RuneError && size == 1 {
+				return "", &IJSONError{Pointer: ptr, Reason: "invalid UTF-8"}
+			}
+			if isNoncharacter(r) {
+				return "", &IJSONError{Pointer: ptr, Reason: fmt.Sprintf("noncharacter U+%04X", r)}
+			}
+			sb.WriteRune(r)
+			s.i += size
+		}
+	}
+}
+
+// escape decodes one escape sequence; a \u surrogate must be part of a
+// valid pair.
+func (s *ijsonScanner) escape(ptr string) (rune, error) {
+	c := s.b[s.i+1]
+	s.i += 2
+	switch c {
+	case 'b':
+		return '\b', nil
+	case 'f':
+		return '\f', nil
+	case 'n':
+		return '\n', nil
+	case 'r':
+		return '\r', nil
+	case 't':
+		return '\t', nil
+	case 'u':
+	default:
+		return rune(c), nil
+	}
+
+	r := s.hex4()
+	if !utf16.IsSurrogate(r) {
+		return r, nil
+	}
+	if r < 0xdc00 && s.i+6 <= len(s.b) && s.b[s.i] == '\\' && s.b[s.i+1] == 'u' {
+		save := s.i
+		s.i += 2
+		if lo := s.hex4(); lo >= 0xdc00 && lo <= 0xdfff {
+			return utf16.DecodeRune(r, lo), nil
+		}
+		s.i = save
+	}
+	return 0, &IJSONError{Pointer: ptr, Reason: fmt.Sprintf("lone surrogate \\u%04x", r)}
+}
+
+func (s *ijsonScanner) hex4() rune {
+	v, _ := strconv.ParseUint(string(s.b[s.i:s.i+4]), 16, 32)
+	s.i += 4
+	return rune(v)
+}
+
+func (s *ijsonScanner) number(ptr string) error {
+	start := s.i
+	for s.i < len(s.b) && strings.IndexByte("+-0123456789.eE", s.b[s.i]) >= 0 {
+		s.i++
+	}
+	lit := string(s.b[start:s.i])
+
+	f, err := strconv.ParseFloat(lit, 64)
+	if err != nil || math.IsInf(f, 0) {
+		return &IJSONError{Pointer: ptr, Reason: fmt.Sprintf("number %s overflows IEEE 754 double", lit)}
+	}
+	if !strings.ContainsAny(lit, ".eE") && math.Abs(f) > maxSafeInteger {
+		return &IJSONError{Pointer: ptr, Reason: fmt.Sprintf("integer %s is outside ±(2^53-1)", lit)}
+	}
+	return nil
+}
+
+// isNoncharacter reports whether r is one of the 66 Unicode noncharacters.
+func isNoncharacter(r rune) bool {
+	return (r >= 0xfdd0 && r <= 0xfdef) || r&0xfffe == 0xfffe
+}
+
+// EscapePointerToken escapes a member name for use as a JSON pointer
+// reference token (RFC 6901 section 3): "~" becomes "~0" and "/" becomes
+// "~1".
+func EscapePointerToken(s string) string {
+	s = strings.ReplaceAll(s, "~", "~0")
+	return strings.ReplaceAll(s, "/", "~1")
+}
@@ -0,0 +1,51 @@
+package canonical
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCanonicalizeStrict_OK(t *testing.T) {
+	out, err := CanonicalizeStrict([]byte(`{"b":[9007199254740991,-9007199254740991,1e300,0.5],"a":"😀"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `{"a":"😀","b":[9007199254740991,-9007199254740991,1e+300,0.5]}`
+	if string(out) != want {
+		t.Fatalf("want %q, got %q", want, string(out))
+	}
+}
+
+func TestCanonicalizeStrict_Violations(t *testing.T) {
+	cases := []struct {
+		in      string
+		pointer string
+	}{
+		{`{"a":1,"a":2}`, "/a"},
+		{`{"x":[{"k":1},{"k":1,"k":2}]}`, "/x/1/k"},
+		{`{"a/b":{"c~d":"\ud800"}}`, "/a~1b/c~0d"},
+		{`["ok","\udc00x"]`, "/1"},
+		{`{"n":9007199254740992}`, "/n"},
+		{`[-9007199254740993]`, "/0"},
+		{`{"f":1e400}`, "/f"},
+		{`{"s":"￿"}`, "/s"},
+		{"[\"\xff\"]", "/0"},
+	}
+	for _, c := range cases {
+		_, err := CanonicalizeStrict([]byte(c.in))
+		if !errors.Is(err, ErrNotIJSON) {
+			t.Fatalf("input %s: want ErrNotIJSON, got %v", c.in, err)
+		}
+		var ie *IJSONError
+		if !errors.As(err, &ie) || ie.Pointer != c.pointer {
+			t.Fatalf("input %s: want pointer %q, got %v", c.in, c.pointer, err)
+		}
+	}
+}
+
+func TestCanonicalizeStrict_InvalidJSONRejected(t *testing.T) {
+	_, err := CanonicalizeStrict([]byte(`{"a":1 "b":2}`))
+	if !errors.Is(err, ErrInvalidJSON) {
+		t.Fatalf("want ErrInvalidJSON, got %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+package canonical
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	ErrTooLarge      = errors.New("json input too large")
+	ErrTooDeep       = errors.New("json nesting too deep")
+	ErrTooManyKeys   = errors.New("json object has too many members")
+	ErrStringTooLong = errors.New("json string too long")
+)
+
+// Limits bounds the JSON accepted from untrusted input. A zero field means
+// no limit.
+type Limits struct {
+	// MaxDepth is the maximum nesting depth of objects and arrays.
+	MaxDepth int
+	// MaxSize is the maximum input size in bytes.
+	MaxSize int64
+	// MaxKeys is the maximum number of members of a single object.
+	MaxKeys int
+	// MaxStringLength is the maximum length of a string (member names
+	// included) in bytes, as written in the input.
+	MaxStringLength int
+}
+
+// DefaultLimits is applied by Canonicalize, CanonicalizeStrict and, except
+// for MaxSize, CanonicalizeStream.
+var DefaultLimits = Limits{
+	MaxDepth:        128,
+	MaxSize:         256 << 20,
+	MaxKeys:         100000,
+	MaxStringLength: 64 << 20,
+}
+
+// LimitError reports which limit was exceeded and where. It matches one of
+// ErrTooLarge, ErrTooDeep, ErrTooManyKeys and ErrStringTooLong.
+type LimitError struct {
+	Err    error
+	Limit  int64
+	Offset int64
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%v (limit %d) at offset %d", e.Err, e.Limit, e.Offset)
+}
+
+func (e *LimitError) Unwrap() error { return e.Err }
+
+// CheckLimits checks input against l without decoding it. It runs in a
+// single pass with memory proportional to the nesting depth, so it is safe
+// to call before handing input to a decoder. Syntax errors are left to the
+// decoder.
+func CheckLimits(input []byte, l Limits) error {
+	c := limitChecker{l: l}
+	return c.feed(input)
+}
+
+// CanonicalizeWithLimits is Canonicalize with explicit limits.
+func CanonicalizeWithLimits(input []byte, l Limits) ([]byte, error) {
+	if err := CheckLimits(input, l); err != nil {
+		return nil, err
+	}
+	return canonicalize(input)
+}
+
+// CanonicalizeStreamWithLimits is CanonicalizeStream with explicit limits;
+// the limits are enforced on the bytes as they are read.
+func CanonicalizeStreamWithLimits(w io.Writer, r io.Reader, l Limits) error {
+	return canonicalizeStream(w, &limitReader{r: r, c: limitChecker{l: l}}, false)
+}
+
+type limitReader struct {
+	r io.Reader
+	c limitChecker
+}
+
+func (lr *limitReader) Read(p []byte) (int, error) {
+	n, err := lr.r.Read(p)
+	if cerr := lr.c.feed(p[:n]); cerr != nil {
+		return 0, cerr
+	}
+	return n, err
+}
+
+// limitChecker is a byte-level state machine that tracks just enough JSON
+// structure (strings, nesting, members) to enforce Limits incrementally.
+type limitChecker struct {
+	l        Limits
+	offset   int64
+	inString bool
+	escaped  bool
+	strLen   int
+	// stack holds the member count of each open object, or -1 for arrays.
+	stack []int
+}
+
+func (c *limitChecker) fail(err error, limit int64) error {
+	return &LimitError{Err: err, Limit: limit, Offset: c.offset}
+}
+
+func (c *limitChecker) feed(p []byte) error {
+	for _, b := range p {
+		if c.l.MaxSize > 0 && c.offset >= c.l.MaxSize {
+			return c.fail(ErrTooLarge, c.l.MaxSize)
+		}
+
+		if c.inString {
+			switch {
+			case c.escaped:
+				c.escaped = false
+			case b == '\\':
+				c.escaped = true
+			case b == '"':
+				c.inString = false
+			}
+			if c.inString {
+				c.strLen++
+				if c.l.MaxStringLength > 0 && c.strLen > c.l.MaxStringLength {
+					return c.fail(ErrStringTooLong, int64(c.l.MaxStringLength))
+				}
+			}
+			c.offset++
+			continue
+		}
+
+		switch b {
+		case '"':
+			c.inString = true
+			c.strLen = 0
+		case '{', '[':
+			if c.l.MaxDepth > 0 && len(c.stack) >= c.l.MaxDepth {
+				return c.fail(ErrTooDeep, int64(c.l.MaxDepth))
+			}
+			if b == '{' {
+				c.stack = append(c.stack, 0)
+			} else {
+				c.stack = append(c.stack, -1)
+			}
+		case '}', ']':
+			if len(c.stack) > 0 {
+				c.stack = c.stack[:len(c.stack)-1]
+			}
+		case ':':
+			if n := len(c.stack); n > 0 && c.stack[n-1] >= 0 {
+				c.stack[n-1]++
+				if c.l.MaxKeys > 0 && c.stack[n-1] > c.l.MaxKeys {
+					return c.fail(ErrTooManyKeys, int64(c.l.MaxKeys))
+				}
+			}
+		}
+		c.offset++
+	}
+	return nil
+}
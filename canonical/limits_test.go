@@ -0,0 +1,73 @@
+package canonical
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCanonicalizeWithLimits_Exceeded(t *testing.T) {
+	l := Limits{MaxDepth: 3, MaxSize: 64, MaxKeys: 2, MaxStringLength: 8}
+	cases := []struct {
+		in   string
+		want error
+	}{
+		{`[[[[1]]]]`, ErrTooDeep},
+		{`{"a":[{"b":{"c":1}}]}`, ErrTooDeep},
+		{`[` + strings.Repeat(`1,`, 40) + `1]`, ErrTooLarge},
+		{`{"a":1,"b":2,"c":3}`, ErrTooManyKeys},
+		{`["123456789"]`, ErrStringTooLong},
+		{`{"123456789":1}`, ErrStringTooLong},
+	}
+	for _, c := range cases {
+		_, err := CanonicalizeWithLimits([]byte(c.in), l)
+		if !errors.Is(err, c.want) {
+			t.Fatalf("input %s: want %v, got %v", c.in, c.want, err)
+		}
+		var le *LimitError
+		if !errors.As(err, &le) {
+			t.Fatalf("input %s: want *LimitError, got %T", c.in, err)
+		}
+	}
+}
+
+func TestCanonicalizeWithLimits_WithinLimits_OK(t *testing.T) {
+	l := Limits{MaxDepth: 3, MaxSize: 64, MaxKeys: 2, MaxStringLength: 8}
+	// braces, colons and escaped quotes inside strings do not count
+	out, err := CanonicalizeWithLimits([]byte(`{"b":[["{[:"]],"a":"\"\"\"\""}`), l)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(out) != `{"a":"\"\"\"\"","b":[["{[:"]]}` {
+		t.Fatalf("unexpected output: %s", out)
+	}
+}
+
+func TestCanonicalize_DefaultDepthLimit(t *testing.T) {
+	deep := strings.Repeat("[", 10000) + strings.Repeat("]", 10000)
+	if _, err := Canonicalize([]byte(deep)); !errors.Is(err, ErrTooDeep) {
+		t.Fatalf("want ErrTooDeep, got %v", err)
+	}
+}
+
+func TestCanonicalizeStreamWithLimits_Exceeded(t *testing.T) {
+	l := Limits{MaxDepth: 2, MaxStringLength: 4}
+	err := CanonicalizeStreamWithLimits(io.Discard, strings.NewReader(`[[[1]]]`), l)
+	if !errors.Is(err, ErrTooDeep) {
+		t.Fatalf("want ErrTooDeep, got %v", err)
+	}
+	err = CanonicalizeStreamWithLimits(io.Discard, strings.NewReader(`["`+strings.Repeat("x", 1<<20)+`"]`), l)
+	if !errors.Is(err, ErrStringTooLong) {
+		t.Fatalf("want ErrStringTooLong, got %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := CanonicalizeStreamWithLimits(&out, strings.NewReader(`[{"b":1,"a":2}]`), l); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.String() != `[{"a":2,"b":1}]` {
+		t.Fatalf("unexpected output: %s", out.String())
+	}
+}
@@ -0,0 +1,647 @@
+package canonical
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	jcs "github.com/gowebpki/jcs"
+)
+
+// CanonicalizeStream reads one JSON document from r and writes its JCS
+// canonical form to w, producing the same bytes as Canonicalize and failing
+// on the same input: duplicate member names, a \u surrogate that is not
+// followed by a second \u escape, and so on. Invalid UTF-8 inside strings is
+// copied through unchanged, as Canonicalize does.
+//
+// The input is scanned rather than loaded, and array elements are written
+// to w as soon as they are canonicalized. Only arrays stream, though: every
+// member of an object is buffered until the object ends, so that members
+// can be sorted, and that includes everything nested in it. A top-level
+// array of records ([{...},{...}]) streams in memory bounded by its largest
+// record, but a document wrapped in an object ({"items":[...]}) is held in
+// memory in full. DefaultLimits apply except MaxSize. On error, part of the
+// output may already have been written.
+func CanonicalizeStream(w io.Writer, r io.Reader) error {
+	l := DefaultLimits
+	l.MaxSize = 0
+	return CanonicalizeStreamWithLimits(w, r, l)
+}
+
+// CanonicalizeStreamStrict is CanonicalizeStream for I-JSON input only: it
+// rejects what CanonicalizeStrict rejects, with the same errors, so that a
+// payload too large to load can still be checked for I-JSON while it is
+// canonicalized.
+func CanonicalizeStreamStrict(w io.Writer, r io.Reader) error {
+	l := DefaultLimits
+	l.MaxSize = 0
+	return canonicalizeStream(w, &limitReader{r: r, c: limitChecker{l: l}}, true)
+}
+
+func canonicalizeStream(w io.Writer, r io.Reader, strict bool) error {
+	s := streamScanner{r: bufio.NewReader(r), strict: strict}
+	if _, err := s.r.Peek(1); err == io.EOF {
+		return ErrEmptyInput
+	} else if err != nil {
+		return err
+	}
+
+	c, err := s.skipWS()
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	if c != '{' && c != '[' {
+		// Canonicalize checks the syntax, then the top-level type.
+		if err := s.value(bufio.NewWriter(io.Discard), c); err != nil {
+			return err
+		}
+		if err := s.end(); err != nil {
+			return err
+		}
+		if s.ijsonErr != nil {
+			return s.ijsonErr
+		}
+		return ErrTopLevelNotObjArray
+	}
+	if err := s.value(bw, c); err != nil {
+		return err
+	}
+	if err := s.end(); err != nil {
+		return err
+	}
+	// Like Canonicalize (and CanonicalizeStrict), report I-JSON and then JCS
+	// errors only for syntactically valid input.
+	if s.ijsonErr != nil {
+		return s.ijsonErr
+	}
+	if s.jcsErr != nil {
+		return s.jcsErr
+	}
+	return bw.Flush()
+}
+
+// streamScanner reads JSON byte by byte, following encoding/json for the
+// syntax and gowebpki/jcs (which Canonicalize uses) for everything else.
+// When strict, it also checks the input against RFC 7493 as ValidateIJSON
+// does.
+type streamScanner struct {
+	r      *bufio.Reader
+	strict bool
+	// jcsErr is the first error jcs.Transform would report on input that is
+	// otherwise valid JSON, and ijsonErr the first I-JSON violation. Scanning
+	// goes on so that syntax errors still take precedence, as they do in
+	// Canonicalize.
+	jcsErr   error
+	ijsonErr error
+	// path leads to the value being scanned, for IJSONError.Pointer.
+	path []pathToken
+	// objects holds a member buffer per nesting level, reused from one
+	// object to the next.
+	objects []*objectBuffer
+}
+
+type objectBuffer struct {
+	buf bytes.Buffer
+	w   *bufio.Writer
+}
+
+// objectBuffer returns an empty buffer for the members of an object at the
+// current path. Objects nested in it are further down the path, so they
+// never share it.
+func (s *streamScanner) objectBuffer() *objectBuffer {
+	d := len(s.path)
+	for len(s.objects) <= d {
+		s.objects = append(s.objects, nil)
+	}
+	ob := s.objects[d]
+	if ob == nil {
+		ob = &objectBuffer{}
+		ob.w = bufio.NewWriter(&ob.buf)
+		s.objects[d] = ob
+	}
+	ob.buf.Reset()
+	return ob
+}
+
+// pathToken is a member name, or an array index when index >= 0.
+type pathToken struct {
+	name  string
+	index int
+}
+
+func (s *streamScanner) fail(err error) {
+	if s.jcsErr == nil {
+		s.jcsErr = err
+	}
+}
+
+// violate records an I-JSON violation at the current path when strict.
+func (s *streamScanner) violate(reason string) {
+	if s.strict && s.ijsonErr == nil {
+		s.ijsonErr = &IJSONError{Pointer: s.pointer(), Reason: reason}
+	}
+}
+
+func (s *streamScanner) pointer() string {
+	var b strings.Builder
+	for _, t := range s.path {
+		b.WriteByte('/')
+		if t.index >= 0 {
+			b.WriteString(strconv.Itoa(t.index))
+		} else {
+			b.WriteString(EscapePointerToken(t.name))
+		}
+	}
+	return b.String()
+}
+
+// next returns the next byte; the input ending here is a syntax error.
+func (s *streamScanner) next() (byte, error) {
+	c, err := s.r.ReadByte()
+	if err == io.EOF {
+		return 0, ErrInvalidJSON
+	}
+	return c, err
+}
+
+func (s *streamScanner) skipWS() (byte, error) {
+	for {
+		c, err := s.next()
+		if err != nil {
+			return 0, err
+		}
+		if !isSpace(c) {
+			return c, nil
+		}
+	}
+}
+
+// end checks that only whitespace follows the document.
+func (s *streamScanner) end() error {
+	for {
+		c, err := s.r.ReadByte()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !isSpace(c) {
+			return ErrInvalidJSON
+		}
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+type member struct {
+	key   []uint16
+	name  string
+	value []byte
+}
+
+// value writes the canonical form of the value whose first byte is c.
+func (s *streamScanner) value(w *bufio.Writer, c byte) error {
+	switch c {
+	case '{':
+		return s.object(w)
+	case '[':
+		return s.array(w)
+	case '"':
+		str, err := s.str()
+		if err != nil {
+			return err
+		}
+		writeString(w, str)
+		return nil
+	case 't':
+		return s.literal(w, "true")
+	case 'f':
+		return s.literal(w, "false")
+	case 'n':
+		return s.literal(w, "null")
+	}
+	return s.number(w, c)
+}
+
+func (s *streamScanner) literal(w *bufio.Writer, lit string) error {
+	for i := 1; i < len(lit); i++ {
+		c, err := s.next()
+		if err != nil {
+			return err
+		}
+		if c != lit[i] {
+			return ErrInvalidJSON
+		}
+	}
+	w.WriteString(lit)
+	return nil
+}
+
+func (s *streamScanner) number(w *bufio.Writer, c byte) error {
+	lit := []byte{c}
+	for {
+		c, err := s.r.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if (c < '0' || c > '9') && c != '-' && c != '+' && c != '.' && c != 'e' && c != 'E' {
+			if err := s.r.UnreadByte(); err != nil {
+				return err
+			}
+			break
+		}
+		lit = append(lit, c)
+	}
+	if !validNumber(lit) {
+		return ErrInvalidJSON
+	}
+	f, err := strconv.ParseFloat(string(lit), 64)
+	if err != nil {
+		// Out of range: encoding/json rejects it, I-JSON reports it.
+		if !s.strict {
+			return ErrInvalidJSON
+		}
+		s.violate(fmt.Sprintf("number %s overflows IEEE 754 double", lit))
+		return nil
+	}
+	if s.strict && !bytes.ContainsAny(lit, ".eE") && math.Abs(f) > maxSafeInteger {
+		s.violate(fmt.Sprintf("integer %s is outside ±(2^53-1)", lit))
+	}
+	out, err := jcs.NumberToJSON(f)
+	if err != nil {
+		return ErrInvalidJSON
+	}
+	w.WriteString(out)
+	return nil
+}
+
+// validNumber reports whether b matches the JSON number grammar
+// -?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?.
+func validNumber(b []byte) bool {
+	digits := func(i int) int {
+		for i < len(b) && b[i] >= '0' && b[i] <= '9' {
+			i++
+		}
+		return i
+	}
+	i := 0
+	if i < len(b) && b[i] == '-' {
+		i++
+	}
+	switch {
+	case i < len(b) && b[i] == '0':
+		i++
+	case i < len(b) && b[i] >= '1' && b[i] <= '9':
+		i = digits(i)
+	default:
+		return false
+	}
+	if i < len(b) && b[i] == '.' {
+		j := digits(i + 1)
+		if j == i+1 {
+			return false
+		}
+		i = j
+	}
+	if i < len(b) && (b[i] == 'e' || b[i] == 'E') {
+		i++
+		if i < len(b) && (b[i] == '+' || b[i] == '-') {
+			i++
+		}
+		j := digits(i)
+		if j == i {
+			return false
+		}
+		i = j
+	}
+	return i == len(b)
+}
+
+func (s *streamScanner) array(w *bufio.Writer) error {
+	w.WriteByte('[')
+	c, err := s.skipWS()
+	if err != nil {
+		return err
+	}
+	s.path = append(s.path, pathToken{})
+	for i := 0; c != ']'; i++ {
+		if i > 0 {
+			if c != ',' {
+				return ErrInvalidJSON
+			}
+			w.WriteByte(',')
+			if c, err = s.skipWS(); err != nil {
+				return err
+			}
+		}
+		s.path[len(s.path)-1].index = i
+		if err := s.value(w, c); err != nil {
+			return err
+		}
+		if c, err = s.skipWS(); err != nil {
+			return err
+		}
+	}
+	s.path = s.path[:len(s.path)-1]
+	w.WriteByte(']')
+	return nil
+}
+
+func (s *streamScanner) object(w *bufio.Writer) error {
+	var members []member
+	ob := s.objectBuffer()
+	buf, bw := &ob.buf, ob.w
+	var seen map[string]struct{}
+	if s.strict {
+		seen = map[string]struct{}{}
+	}
+
+	c, err := s.skipWS()
+	if err != nil {
+		return err
+	}
+	for c != '}' {
+		if len(members) > 0 {
+			if c != ',' {
+				return ErrInvalidJSON
+			}
+			if c, err = s.skipWS(); err != nil {
+				return err
+			}
+		}
+		if c != '"' {
+			return ErrInvalidJSON
+		}
+		name, err := s.str()
+		if err != nil {
+			return err
+		}
+		if c, err = s.skipWS(); err != nil {
+			return err
+		}
+		if c != ':' {
+			return ErrInvalidJSON
+		}
+		if c, err = s.skipWS(); err != nil {
+			return err
+		}
+		s.path = append(s.path, pathToken{name: name, index: -1})
+		if seen != nil {
+			if _, dup := seen[name]; dup {
+				s.violate(fmt.Sprintf("duplicate member name %q", name))
+			}
+			seen[name] = struct{}{}
+		}
+		if err := s.value(bw, c); err != nil {
+			return err
+		}
+		s.path = s.path[:len(s.path)-1]
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+		members = append(members, member{
+			// []rune turns invalid UTF-8 into U+FFFD, as in jcs.
+			key:   utf16.Encode([]rune(name)),
+			name:  name,
+			value: bytes.Clone(buf.Bytes()),
+		})
+		buf.Reset()
+		if c, err = s.skipWS(); err != nil {
+			return err
+		}
+	}
+
+	// RFC 8785 sorts member names by their UTF-16 code units.
+	sort.SliceStable(members, func(i, j int) bool {
+		return lessUTF16(members[i].key, members[j].key)
+	})
+	for i := 1; i < len(members); i++ {
+		if slices.Equal(members[i-1].key, members[i].key) {
+			s.fail(fmt.Errorf("Duplicate key: %s", members[i-1].name))
+			break
+		}
+	}
+
+	w.WriteByte('{')
+	for i, m := range members {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		writeString(w, m.name)
+		w.WriteByte(':')
+		w.Write(m.value)
+	}
+	w.WriteByte('}')
+	return nil
+}
+
+func lessUTF16(a, b []uint16) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}
+
+// surrogateFollower checks the two bytes after a \u surrogate the way jcs
+// reads them, with the same errors.
+func surrogateFollower(p []byte) error {
+	for _, c := range p {
+		if c >= utf8.RuneSelf {
+			return errors.New("Unexpected non-ASCII character")
+		}
+	}
+	if len(p) < 2 {
+		return errors.New("Unexpected EOF reached")
+	}
+	if p[0] != '\\' || p[1] != 'u' {
+		return errors.New("Missing surrogate")
+	}
+	return nil
+}
+
+// str decodes the rest of a string whose opening quote has been read. Bytes
+// outside escapes are kept as they are, valid UTF-8 or not.
+func (s *streamScanner) str() (string, error) {
+	var b []byte
+	// run is where the bytes copied as they are since the last escape start.
+	run := 0
+	for {
+		c, err := s.next()
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case c == '"':
+			s.checkUTF8(b[run:])
+			return string(b), nil
+		case c < 0x20:
+			return "", ErrInvalidJSON
+		case c != '\\':
+			b = append(b, c)
+			continue
+		}
+
+		s.checkUTF8(b[run:])
+		r, ok, err := s.escape()
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			b = utf8.AppendRune(b, r)
+		}
+		run = len(b)
+	}
+}
+
+// escape decodes the escape sequence after a backslash. ok is false when a
+// \u surrogate without a second \u escape is dropped, as jcs drops it.
+func (s *streamScanner) escape() (r rune, ok bool, err error) {
+	c, err := s.next()
+	if err != nil {
+		return 0, false, err
+	}
+	switch c {
+	case '"', '\\', '/':
+		return rune(c), true, nil
+	case 'b':
+		return '\b', true, nil
+	case 'f':
+		return '\f', true, nil
+	case 'n':
+		return '\n', true, nil
+	case 'r':
+		return '\r', true, nil
+	case 't':
+		return '\t', true, nil
+	case 'u':
+	default:
+		return 0, false, ErrInvalidJSON
+	}
+
+	if r, err = s.hex4(); err != nil {
+		return 0, false, err
+	}
+	if !utf16.IsSurrogate(r) {
+		s.checkRune(r)
+		return r, true, nil
+	}
+	// jcs takes the next \u escape as the second half of the pair whatever
+	// it is, and fails if there is none.
+	p, err := s.r.Peek(2)
+	if err != nil && err != io.EOF {
+		return 0, false, err
+	}
+	if err := surrogateFollower(p); err != nil {
+		s.fail(err)
+		s.violate(fmt.Sprintf("lone surrogate \\u%04x", r))
+		return 0, false, nil
+	}
+	s.r.Discard(2)
+	r2, err := s.hex4()
+	if err != nil {
+		return 0, false, err
+	}
+	if r >= 0xdc00 || r2 < 0xdc00 || r2 > 0xdfff {
+		s.violate(fmt.Sprintf("lone surrogate \\u%04x", r))
+	}
+	r = utf16.DecodeRune(r, r2)
+	s.checkRune(r)
+	return r, true, nil
+}
+
+// checkUTF8 checks bytes copied from the input for invalid UTF-8 and
+// noncharacters when strict.
+func (s *streamScanner) checkUTF8(p []byte) {
+	if !s.strict {
+		return
+	}
+	for len(p) > 0 {
+		r, size := utf8.DecodeRune(p)
+		if r == utf8.RuneError && size == 1 {
+			s.violate("invalid UTF-8")
+			return
+		}
+		s.checkRune(r)
+		p = p[size:]
+	}
+}
+
+func (s *streamScanner) checkRune(r rune) {
+	if s.strict && isNoncharacter(r) {
+		s.violate(fmt.Sprintf("noncharacter U+%04X", r))
+	}
+}
+
+func (s *streamScanner) hex4() (rune, error) {
+	var r rune
+	for i := 0; i < 4; i++ {
+		c, err := s.next()
+		if err != nil {
+			return 0, err
+		}
+		switch {
+		case c >= '0' && c <= '9':
+			c -= '0'
+		case c >= 'a' && c <= 'f':
+			c -= 'a' - 10
+		case c >= 'A' && c <= 'F':
+			c -= 'A' - 10
+		default:
+			return 0, ErrInvalidJSON
+		}
+		r = r<<4 | rune(c)
+	}
+	return r, nil
+}
+
+// writeString writes s as an RFC 8785 string: only '"', '\\' and control
+// characters are escaped, everything else is written as is.
+func writeString(w *bufio.Writer, s string) {
+	const hex = "0123456789abcdef"
+	w.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '"', '\\':
+			w.WriteByte('\\')
+			w.WriteByte(c)
+		case '\b':
+			w.WriteString(`\b`)
+		case '\f':
+			w.WriteString(`\f`)
+		case '\n':
+			w.WriteString(`\n`)
+		case '\r':
+			w.WriteString(`\r`)
+		case '\t':
+			w.WriteString(`\t`)
+		default:
+			if c < 0x20 {
+				w.WriteString(`\u00`)
+				w.WriteByte(hex[c>>4])
+				w.WriteByte(hex[c&0xf])
+			} else {
+				w.WriteByte(c)
+			}
+		}
+	}
+	w.WriteByte('"')
+}
@@ -0,0 +1,169 @@
+package canonical
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCanonicalizeStream_MatchesCanonicalize(t *testing.T) {
+	inputs := []string{
+		`{}`,
+		`[]`,
+		`{"b":1,"a":2}`,
+		`[1, 2.50, -0, 1e21, 1E-7, 100000000000000000000]`,
+		`{"nested":{"z":[true,false,null],"a":{"y":"x"}},"arr":[{"b":1,"a":2}]}`,
+		`{"esc":"\"\\\/\b\f\n\r\t\u0001\u001f","uni":"é😀€"}`,
+		`{"€":1,"😀":2,"a":3,"é":4}`,
+		` [ {"k" : "v"} , "s" ] `,
+		`{"\u00e9":"\ud83d\ude00","e\u0301":"\u20AC"}`,
+		"{\"a\":\"\xff\",\"b\":\"\xc3\"}",
+		"{\"\xff\":1}",
+		`["\ud800\u0041","\udc00\udc00"]`,
+
+		// rejected by both
+		`{"a":1,"a":2}`,
+		`[{"b":{"x":1,"x":1}}]`,
+		`{"\u00e9":1,"é":2}`,
+		"{\"\xff\":1,\"\xfe\":2}",
+		`["\ud800"]`,
+		`{"a":"\udc00x"}`,
+	}
+	for _, in := range inputs {
+		want, wantErr := Canonicalize([]byte(in))
+		var got bytes.Buffer
+		err := CanonicalizeStream(&got, strings.NewReader(in))
+		if wantErr != nil {
+			if err == nil || err.Error() != wantErr.Error() {
+				t.Fatalf("input %q: want error %v, got %v", in, wantErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("CanonicalizeStream(%q): %v", in, err)
+		}
+		if got.String() != string(want) {
+			t.Fatalf("input %q: want %q, got %q", in, want, got.String())
+		}
+	}
+}
+
+func TestCanonicalizeStreamStrict_MatchesCanonicalizeStrict(t *testing.T) {
+	inputs := []string{
+		`{"b":[9007199254740991,-9007199254740991,1e300,0.5],"a":"😀"}`,
+		`{"\u00e9":"\ud83d\ude00","e\u0301":"\u20AC"}`,
+		`[{"k":1},{"k":2}]`,
+
+		// not I-JSON
+		`{"a":1,"a":2}`,
+		`{"x":[{"k":1},{"k":1,"k":2}]}`,
+		`{"a/b":{"c~d":"\ud800"}}`,
+		`["ok","\udc00x"]`,
+		`["\ud800\u0041"]`,
+		`{"n":9007199254740992}`,
+		`[-9007199254740993]`,
+		`{"f":1e400}`,
+		`{"s":"\uffff"}`,
+		`{"s":"\ud83f\udffe"}`,
+		"{\"s\":\"\xef\xbf\xbf\"}",
+		"[\"\xff\"]",
+		"{\"\xff\":1}",
+		"[\"\xc3\\n\"]",
+		`["a","\ud800",{"a":1,"a":2}]`,
+		`1e400`,
+
+		// syntax errors come first
+		`{"a":1,"a":2,}`,
+		`["\ud800",01]`,
+		`[1e400`,
+	}
+	for _, in := range inputs {
+		want, wantErr := CanonicalizeStrict([]byte(in))
+		var got bytes.Buffer
+		err := CanonicalizeStreamStrict(&got, strings.NewReader(in))
+		if wantErr != nil {
+			if err == nil || err.Error() != wantErr.Error() {
+				t.Fatalf("input %q: want error %v, got %v", in, wantErr, err)
+			}
+			if errors.Is(wantErr, ErrNotIJSON) != errors.Is(err, ErrNotIJSON) {
+				t.Fatalf("input %q: want %v, got %v", in, wantErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("CanonicalizeStreamStrict(%q): %v", in, err)
+		}
+		if got.String() != string(want) {
+			t.Fatalf("input %q: want %q, got %q", in, want, got.String())
+		}
+	}
+}
+
+func TestCanonicalizeStream_Errors(t *testing.T) {
+	cases := []struct {
+		in   string
+		want error
+	}{
+		{``, ErrEmptyInput},
+		{`123`, ErrTopLevelNotObjArray},
+		{`"s"`, ErrTopLevelNotObjArray},
+		{`{"a":1 "b":2}`, ErrInvalidJSON},
+		{`[1,2`, ErrInvalidJSON},
+		{`{"a":1}{}`, ErrInvalidJSON},
+		{`[1e400]`, ErrInvalidJSON},
+		{` `, ErrInvalidJSON},
+		{`[01]`, ErrInvalidJSON},
+		{`[1.]`, ErrInvalidJSON},
+		{`[tru]`, ErrInvalidJSON},
+		{`["\q"]`, ErrInvalidJSON},
+		{"[\"\x01\"]", ErrInvalidJSON},
+		{`[1,]`, ErrInvalidJSON},
+		{`{"a":1,"a":2} x`, ErrInvalidJSON},
+		{`"\ud800"`, ErrTopLevelNotObjArray},
+	}
+	for _, c := range cases {
+		err := CanonicalizeStream(io.Discard, strings.NewReader(c.in))
+		if !errors.Is(err, c.want) {
+			t.Fatalf("input %q: want %v, got %v", c.in, c.want, err)
+		}
+	}
+}
+
+// largeArray generates a JSON array of n records without materializing it.
+func largeArray(n int) io.Reader {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.Write([]byte("["))
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				pw.Write([]byte(","))
+			}
+			fmt.Fprintf(pw, `{"id":%d,"name":"record-%d","ok":true}`, i, i)
+		}
+		pw.Write([]byte("]"))
+		pw.Close()
+	}()
+	return pr
+}
+
+func TestCanonicalizeStream_LargeArray(t *testing.T) {
+	const n = 20000
+	var got bytes.Buffer
+	if err := CanonicalizeStream(&got, largeArray(n)); err != nil {
+		t.Fatalf("CanonicalizeStream: %v", err)
+	}
+	in, err := io.ReadAll(largeArray(n))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := Canonicalize(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Fatalf("streamed output differs from Canonicalize")
+	}
+}
@@ -0,0 +1,216 @@
+package canonical
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrInvalidYAML     = errors.New("invalid yaml")
+	ErrUnsupportedYAML = errors.New("unsupported yaml")
+)
+
+// YAMLToJSON converts a YAML stream into a JSON array with one element per
+// (non-empty) document, so that CanonicalizeYAML can hash it with JCS.
+//
+// Typing is strict and follows the YAML 1.2 core schema only: plain scalars
+// become null, booleans, numbers or strings; timestamps stay strings as
+// written, and so do YAML 1.1 numbers the core schema does not know, such
+// as 1_000 or 0b101 (017 is the decimal 17, as in the core schema).
+// Anything without a single JSON reading is rejected with
+// ErrUnsupportedYAML: anchors, aliases and merge keys, tags outside the core
+// schema, non-string mapping keys, duplicate keys, .inf/.nan and integers
+// beyond ±(2^53-1).
+func YAMLToJSON(input []byte) ([]byte, error) {
+	if len(input) == 0 {
+		return nil, ErrEmptyInput
+	}
+	if DefaultLimits.MaxSize > 0 && int64(len(input)) > DefaultLimits.MaxSize {
+		return nil, &LimitError{Err: ErrTooLarge, Limit: DefaultLimits.MaxSize, Offset: DefaultLimits.MaxSize}
+	}
+
+	docs := []any{}
+	dec := yaml.NewDecoder(bytes.NewReader(input))
+	for {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidYAML, err)
+		}
+		// An empty document (e.g. a trailing "---") holds an implicit null.
+		if len(doc.Content) == 0 || isEmptyYAMLScalar(doc.Content[0]) {
+			continue
+		}
+		v, err := yamlValue(doc.Content[0], "")
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, v)
+	}
+	return json.Marshal(docs)
+}
+
+// CanonicalizeYAML returns the JCS form of YAMLToJSON(input).
+func CanonicalizeYAML(input []byte) ([]byte, error) {
+	j, err := YAMLToJSON(input)
+	if err != nil {
+		return nil, err
+	}
+	return Canonicalize(j)
+}
+
+func isEmptyYAMLScalar(n *yaml.Node) bool {
+	return n.Kind == yaml.ScalarNode && n.ShortTag() == "!!null" && n.Value == "" && n.Style&yaml.TaggedStyle == 0
+}
+
+func unsupportedYAML(n *yaml.Node, ptr, reason string) error {
+	return fmt.Errorf("%w: %s at %q (line %d)", ErrUnsupportedYAML, reason, ptr, n.Line)
+}
+
+func yamlValue(n *yaml.Node, ptr string) (any, error) {
+	if n.Anchor != "" {
+		return nil, unsupportedYAML(n, ptr, "anchor &"+n.Anchor)
+	}
+
+	switch n.Kind {
+	case yaml.AliasNode:
+		return nil, unsupportedYAML(n, ptr, "alias *"+n.Value)
+
+	case yaml.MappingNode:
+		if n.ShortTag() != "!!map" {
+			return nil, unsupportedYAML(n, ptr, "tag "+n.Tag)
+		}
+		obj := make(map[string]any, len(n.Content)/2)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, v := n.Content[i], n.Content[i+1]
+			if k.Kind == yaml.ScalarNode && k.ShortTag() == "!!merge" {
+				return nil, unsupportedYAML(k, ptr, "merge key <<")
+			}
+			if k.Kind != yaml.ScalarNode || yamlTag(k) != "!!str" || k.Anchor != "" {
+				return nil, unsupportedYAML(k, ptr, "non-string mapping key")
+			}
+			child := ptr + "/" + EscapePointerToken(k.Value)
+			if _, dup := obj[k.Value]; dup {
+				return nil, unsupportedYAML(k, child, fmt.Sprintf("duplicate key %q", k.Value))
+			}
+			val, err := yamlValue(v, child)
+			if err != nil {
+				return nil, err
+			}
+			obj[k.Value] = val
+		}
+		return obj, nil
+
+	case yaml.SequenceNode:
+		if n.ShortTag() != "!!seq" {
+			return nil, unsupportedYAML(n, ptr, "tag "+n.Tag)
+		}
+		arr := make([]any, 0, len(n.Content))
+		for i, c := range n.Content {
+			val, err := yamlValue(c, ptr+"/"+strconv.Itoa(i))
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, val)
+		}
+		return arr, nil
+
+	case yaml.ScalarNode:
+		return yamlScalar(n, ptr)
+	}
+	return nil, unsupportedYAML(n, ptr, "unexpected node")
+}
+
+// The YAML 1.2 core schema numbers (section 10.3.2).
+var (
+	yamlCoreInt    = regexp.MustCompile(`^(?:[-+]?[0-9]+|0o[0-7]+|0x[0-9a-fA-F]+)$`)
+	yamlCoreFloat  = regexp.MustCompile(`^[-+]?(?:\.[0-9]+|[0-9]+(?:\.[0-9]*)?)(?:[eE][-+]?[0-9]+)?$`)
+	yamlCoreInfNaN = regexp.MustCompile(`^(?:[-+]?\.(?:inf|Inf|INF)|\.(?:nan|NaN|NAN))$`)
+)
+
+// yamlTag returns the tag of n under the YAML 1.2 core schema. yaml.v3
+// resolves plain numbers with YAML 1.1 rules (underscores, 0b, 017 as
+// octal); a plain scalar the core schema does not read as a number is a
+// string.
+func yamlTag(n *yaml.Node) string {
+	tag := n.ShortTag()
+	if n.Kind != yaml.ScalarNode || n.Style&yaml.TaggedStyle != 0 || (tag != "!!int" && tag != "!!float") {
+		return tag
+	}
+	switch {
+	case yamlCoreInt.MatchString(n.Value):
+		return "!!int"
+	case yamlCoreFloat.MatchString(n.Value), yamlCoreInfNaN.MatchString(n.Value):
+		return "!!float"
+	}
+	return "!!str"
+}
+
+// parseYAMLInt parses an integer in one of the core schema forms.
+func parseYAMLInt(s string) (int64, error) {
+	if !yamlCoreInt.MatchString(s) {
+		return 0, strconv.ErrSyntax
+	}
+	switch {
+	case strings.HasPrefix(s, "0o"):
+		return strconv.ParseInt(s[2:], 8, 64)
+	case strings.HasPrefix(s, "0x"):
+		return strconv.ParseInt(s[2:], 16, 64)
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+func yamlScalar(n *yaml.Node, ptr string) (any, error) {
+	switch yamlTag(n) {
+	case "!!str":
+		return n.Value, nil
+	case "!!timestamp":
+		// Only implicit (plain) timestamps; keep them as written.
+		if n.Tag == "!!timestamp" && n.Style&yaml.TaggedStyle != 0 {
+			return nil, unsupportedYAML(n, ptr, "tag "+n.Tag)
+		}
+		return n.Value, nil
+	case "!!null":
+		return nil, nil
+	case "!!bool":
+		var b bool
+		if err := n.Decode(&b); err != nil {
+			return nil, unsupportedYAML(n, ptr, "invalid bool "+n.Value)
+		}
+		return b, nil
+	case "!!int":
+		i, err := parseYAMLInt(n.Value)
+		if errors.Is(err, strconv.ErrSyntax) {
+			return nil, unsupportedYAML(n, ptr, "invalid integer "+n.Value)
+		}
+		if err != nil || i > maxSafeInteger || i < -maxSafeInteger {
+			return nil, unsupportedYAML(n, ptr, fmt.Sprintf("integer %s is outside ±(2^53-1)", n.Value))
+		}
+		return json.Number(strconv.FormatInt(i, 10)), nil
+	case "!!float":
+		if yamlCoreInfNaN.MatchString(n.Value) {
+			return nil, unsupportedYAML(n, ptr, "non-finite float "+n.Value)
+		}
+		if !yamlCoreFloat.MatchString(n.Value) {
+			return nil, unsupportedYAML(n, ptr, "invalid float "+n.Value)
+		}
+		f, err := strconv.ParseFloat(n.Value, 64)
+		if err != nil || math.IsInf(f, 0) {
+			return nil, unsupportedYAML(n, ptr, "non-finite float "+n.Value)
+		}
+		return json.Number(strconv.FormatFloat(f, 'g', -1, 64)), nil
+	}
+	return nil, unsupportedYAML(n, ptr, "tag "+n.Tag)
+}
@@ -0,0 +1,105 @@
+package canonical
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCanonicalizeYAML_Formatting_Independent(t *testing.T) {
+	a := []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: demo
+  labels: {app: web, tier: "1"}
+data:
+  replicas: 3
+  ratio: 0.50
+  enabled: true
+  note: ~
+  since: 2024-01-02
+`)
+	b := []byte(`# reformatted
+kind: "ConfigMap"
+apiVersion: 'v1'
+data: {note: null, enabled: true, ratio: 5e-1, replicas: 0x3, since: 2024-01-02}
+metadata:
+  labels:
+    tier: '1'
+    app: web
+  name: demo
+`)
+	ca, err := CanonicalizeYAML(a)
+	if err != nil {
+		t.Fatalf("a: %v", err)
+	}
+	cb, err := CanonicalizeYAML(b)
+	if err != nil {
+		t.Fatalf("b: %v", err)
+	}
+	if string(ca) != string(cb) {
+		t.Fatalf("want equal, got\n%s\n%s", ca, cb)
+	}
+	want := `[{"apiVersion":"v1","data":{"enabled":true,"note":null,"ratio":0.5,"replicas":3,"since":"2024-01-02"},"kind":"ConfigMap","metadata":{"labels":{"app":"web","tier":"1"},"name":"demo"}}]`
+	if string(ca) != want {
+		t.Fatalf("want %s, got %s", want, ca)
+	}
+}
+
+func TestCanonicalizeYAML_MultiDocument(t *testing.T) {
+	out, err := CanonicalizeYAML([]byte("---\na: 1\n---\n- x\n---\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(out) != `[{"a":1},["x"]]` {
+		t.Fatalf("unexpected output: %s", out)
+	}
+}
+
+// Numbers follow the YAML 1.2 core schema, not the YAML 1.1 rules of yaml.v3.
+func TestCanonicalizeYAML_CoreSchemaNumbers(t *testing.T) {
+	in := `decimal: 017
+octal: 0o17
+hex: 0x1F
+signed: +12
+float: 08.50
+underscored: 1_000
+binary: 0b101
+octal11: 0O17
+signedhex: +0x1F
+floatunderscored: 1_0.5
+1_000: key
+`
+	out, err := CanonicalizeYAML([]byte(in))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `[{"1_000":"key","binary":"0b101","decimal":17,"float":8.5,"floatunderscored":"1_0.5","hex":31,"octal":15,"octal11":"0O17","signed":12,"signedhex":"+0x1F","underscored":"1_000"}]`
+	if string(out) != want {
+		t.Fatalf("want %s, got %s", want, out)
+	}
+}
+
+func TestCanonicalizeYAML_Rejected(t *testing.T) {
+	cases := []struct {
+		name, in string
+		want     error
+	}{
+		{"anchor and alias", "a: &x 1\nb: *x\n", ErrUnsupportedYAML},
+		{"merge key", "base: {a: 1}\nc:\n  <<: {a: 2}\n", ErrUnsupportedYAML},
+		{"custom tag", "a: !secret xyz\n", ErrUnsupportedYAML},
+		{"binary tag", "a: !!binary aGVsbG8=\n", ErrUnsupportedYAML},
+		{"non-string key", "1: one\n", ErrUnsupportedYAML},
+		{"duplicate key", "a: 1\na: 2\n", ErrUnsupportedYAML},
+		{"infinity", "a: .inf\n", ErrUnsupportedYAML},
+		{"big integer", "a: 9007199254740993\n", ErrUnsupportedYAML},
+		{"tagged underscored int", "a: !!int 1_000\n", ErrUnsupportedYAML},
+		{"tagged binary int", "a: !!int 0b101\n", ErrUnsupportedYAML},
+		{"syntax", "a: [1, 2\n", ErrInvalidYAML},
+	}
+	for _, c := range cases {
+		_, err := CanonicalizeYAML([]byte(c.in))
+		if !errors.Is(err, c.want) {
+			t.Fatalf("%s: want %v, got %v", c.name, c.want, err)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/na0h/veriseal/core"
+	"github.com/na0h/veriseal/crypto"
+)
+
+type attestResult struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+func runAttest(args []string) error {
+	fs := flag.NewFlagSet("attest", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var subjects, fields stringList
+	privPath := fs.String("privkey", "", "path to ed25519 private key")
+	kid := fs.String("kid", "", "key id of the attester")
+	fs.Var(&subjects, "subject", "artifact file to attest (repeatable)")
+	subjectEnc := fs.String("subject-encoding", core.V1PayloadEncodingRaw, "normalization applied before digesting subjects: raw, jcs, text, cbor, yaml, ndjson, tar or zip")
+	trimWS := fs.Bool("trim-trailing-whitespace", false, "--subject-encoding text: also trim trailing whitespace from every line")
+	predicateType := fs.String("predicate-type", core.SLSAProvenanceV1, "statement predicateType")
+	predicateFile := fs.String("predicate-file", "", "predicate JSON file")
+	fs.Var(&fields, "predicate-field", "predicate field as key=value; dotted keys nest (repeatable)")
+	outPath := fs.String("output", "", "output file path (default: stdout)")
+	jsonOut := fs.Bool("json", false, "output result as JSON (for CI / automation); when set, writes attestation envelope JSON to --output (required)")
+
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printAttestUsage(os.Stdout)
+			return nil
+		}
+		printAttestUsage(os.Stderr)
+		return err
+	}
+
+	var missing string
+	switch {
+	case *privPath == "":
+		missing = "--privkey"
+	case *kid == "":
+		missing = "--kid"
+	case len(subjects) == 0:
+		missing = "--subject"
+	}
+	if missing != "" {
+		printAttestUsage(os.Stderr)
+		if *jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetEscapeHTML(false)
+			_ = enc.Encode(attestResult{OK: false, Error: "missing " + missing})
+		}
+		return fmt.Errorf("missing %s", missing)
+	}
+	if *predicateFile != "" && len(fields) > 0 {
+		printAttestUsage(os.Stderr)
+		if *jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetEscapeHTML(false)
+			_ = enc.Encode(attestResult{OK: false, Error: "--predicate-file cannot be used with --predicate-field"})
+		}
+		return fmt.Errorf("--predicate-file cannot be used with --predicate-field")
+	}
+	if *trimWS && *subjectEnc != core.V1PayloadEncodingText {
+		printAttestUsage(os.Stderr)
+		if *jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetEscapeHTML(false)
+			_ = enc.Encode(attestResult{OK: false, Error: "--trim-trailing-whitespace requires --subject-encoding text"})
+		}
+		return fmt.Errorf("--trim-trailing-whitespace requires --subject-encoding text")
+	}
+	if *jsonOut && *outPath == "" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		_ = enc.Encode(attestResult{OK: false, Error: "missing --output (required when --json is set)"})
+		return fmt.Errorf("missing --output")
+	}
+
+	out, err := attest(*privPath, *kid, subjects, *subjectEnc, *trimWS, *predicateType, *predicateFile, fields)
+	if err != nil {
+		if *jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetEscapeHTML(false)
+			_ = enc.Encode(attestResult{OK: false, Error: err.Error()})
+		}
+		return err
+	}
+
+	if *jsonOut {
+		if err := writeOutput(*outPath, out); err != nil {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetEscapeHTML(false)
+			_ = enc.Encode(attestResult{OK: false, Error: err.Error()})
+			return err
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		_ = enc.Encode(attestResult{OK: true})
+		return nil
+	}
+
+	return writeOutput(*outPath, out)
+}
+
+func attest(privPath, kid string, subjects []string, subjectEnc string, trimWS bool, predicateType, predicateFile string, fields []string) ([]byte, error) {
+	priv, err := crypto.LoadEd25519PrivateKey(privPath)
+	if err != nil {
+		return nil, err
+	}
+
+	st := core.Statement{
+		Type:          core.InTotoStatementTypeV1,
+		PredicateType: predicateType,
+	}
+	for _, path := range subjects {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var s core.Subject
+		if subjectEnc == core.V1PayloadEncodingText {
+			steps := core.DefaultTextNormalization()
+			steps.TrimTrailingWhitespace = trimWS
+			s, err = core.NewTextSubject(filepath.ToSlash(path), b, steps)
+		} else {
+			s, err = core.NewSubject(filepath.ToSlash(path), b, subjectEnc)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("subject %s: %w", path, err)
+		}
+		st.Subject = append(st.Subject, s)
+	}
+
+	switch {
+	case predicateFile != "":
+		b, err := os.ReadFile(predicateFile)
+		if err != nil {
+			return nil, err
+		}
+		if !json.Valid(b) {
+			return nil, fmt.Errorf("invalid predicate JSON: %s", predicateFile)
+		}
+		st.Predicate = b
+	case len(fields) > 0:
+		st.Predicate, err = predicateFromFields(fields)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	signed, err := core.SignAttestationEd25519(st, kid, priv)
+	if err != nil {
+		return nil, err
+	}
+	out, err := json.MarshalIndent(signed, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// predicateFromFields builds a JSON object from key=value pairs. Dotted keys
+// create nested objects; values are strings.
+func predicateFromFields(fields []string) (json.RawMessage, error) {
+	root := map[string]any{}
+	for _, f := range fields {
+		key, val, ok := strings.Cut(f, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --predicate-field (want key=value): %s", f)
+		}
+		obj := root
+		parts := strings.Split(key, ".")
+		for _, p := range parts[:len(parts)-1] {
+			next, ok := obj[p].(map[string]any)
+			if !ok {
+				if _, exists := obj[p]; exists {
+					return nil, fmt.Errorf("invalid --predicate-field (%s is not an object): %s", p, f)
+				}
+				next = map[string]any{}
+				obj[p] = next
+			}
+			obj = next
+		}
+		last := parts[len(parts)-1]
+		if _, exists := obj[last]; exists {
+			return nil, fmt.Errorf("duplicate --predicate-field: %s", key)
+		}
+		obj[last] = val
+	}
+	return json.Marshal(root)
+}
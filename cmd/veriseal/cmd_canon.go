@@ -3,18 +3,26 @@ package main
 import (
 	"errors"
 	"flag"
+	"fmt"
 	"io"
 	"os"
 
+	"github.com/fxamacker/cbor/v2"
 	"github.com/na0h/veriseal/canonical"
 )
 
 func runCanon(args []string) error {
+	if len(args) > 0 && args[0] == "diff" {
+		return runCanonDiff(args[1:])
+	}
+
 	fs := flag.NewFlagSet("canon", flag.ContinueOnError)
 	fs.SetOutput(io.Discard)
 
 	inPath := fs.String("input", "", "input file path (default: stdin)")
 	outPath := fs.String("output", "", "output file path (default: stdout)")
+	format := fs.String("format", formatJSON, "input format: json (JCS), cbor (RFC 8949 core deterministic encoding) or yaml (JCS of the documents)")
+	diag := fs.Bool("diag", false, "cbor only: write RFC 8949 diagnostic notation instead of bytes")
 
 	if err := parseFlags(fs, args); err != nil {
 		if errors.Is(err, flag.ErrHelp) {
@@ -24,19 +32,42 @@ func runCanon(args []string) error {
 		printCanonUsage(os.Stderr)
 		return err
 	}
+	if *diag && *format != formatCBOR {
+		printCanonUsage(os.Stderr)
+		return fmt.Errorf("--diag requires --format cbor")
+	}
 
 	input, err := readInput(*inPath)
 	if err != nil {
 		return err
 	}
 
-	out, err := canonical.Canonicalize(input)
+	var out []byte
+	switch *format {
+	case formatJSON:
+		out, err = canonical.Canonicalize(input)
+		if err == nil && *outPath == "" {
+			out = append(out, '\n')
+		}
+	case formatYAML:
+		out, err = canonical.CanonicalizeYAML(input)
+		if err == nil && *outPath == "" {
+			out = append(out, '\n')
+		}
+	case formatCBOR:
+		out, err = canonical.CanonicalizeCBOR(input)
+		if err == nil && *diag {
+			var s string
+			s, err = cbor.Diagnose(out)
+			out = []byte(s + "\n")
+		}
+	default:
+		printCanonUsage(os.Stderr)
+		return fmt.Errorf("unsupported --format: %s", *format)
+	}
 	if err != nil {
 		return err
 	}
-	if *outPath == "" {
-		out = append(out, '\n')
-	}
 
 	return writeOutput(*outPath, out)
 }
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/na0h/veriseal/canonical"
+	"github.com/na0h/veriseal/core"
+)
+
+type canonDiffSide struct {
+	Path        string `json:"path"`
+	PayloadHash string `json:"payload_hash,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+type canonDiffResult struct {
+	OK          bool                   `json:"ok"`
+	Error       string                 `json:"error,omitempty"`
+	A           *canonDiffSide         `json:"a,omitempty"`
+	B           *canonDiffSide         `json:"b,omitempty"`
+	Differences []canonical.Difference `json:"differences,omitempty"`
+}
+
+func runCanonDiff(args []string) error {
+	fs := flag.NewFlagSet("canon diff", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	jsonOut := fs.Bool("json", false, "output result as JSON")
+
+	positional, err := parseFlagsAndArgs(fs, args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printCanonDiffUsage(os.Stdout)
+			return nil
+		}
+		printCanonDiffUsage(os.Stderr)
+		return err
+	}
+	if len(positional) != 2 {
+		printCanonDiffUsage(os.Stderr)
+		return fmt.Errorf("want exactly two files, got %d", len(positional))
+	}
+
+	res, err := canonDiff(positional[0], positional[1])
+
+	if *jsonOut {
+		res.OK = err == nil
+		if err != nil {
+			res.Error = err.Error()
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		_ = enc.Encode(res)
+		return err
+	}
+
+	if res.A != nil {
+		printCanonDiffSide(os.Stdout, "a", res.A)
+		printCanonDiffSide(os.Stdout, "b", res.B)
+	}
+	for _, d := range res.Differences {
+		printDifference(os.Stdout, d)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stdout, "OK")
+	return nil
+}
+
+// canonDiff compares two JSON files. The error reports that their canonical
+// forms differ, or that either side cannot be canonicalized; differences
+// that only exist in the input text do not fail.
+func canonDiff(pathA, pathB string) (canonDiffResult, error) {
+	var res canonDiffResult
+	a, err := readInput(pathA)
+	if err != nil {
+		return res, err
+	}
+	b, err := readInput(pathB)
+	if err != nil {
+		return res, err
+	}
+	diffs, err := canonical.Diff(a, b)
+	if err != nil {
+		return res, err
+	}
+	res.Differences = diffs
+	res.A = canonDiffHash(pathA, a)
+	res.B = canonDiffHash(pathB, b)
+
+	if res.A.Error != "" || res.B.Error != "" {
+		return res, errors.New("input cannot be canonicalized")
+	}
+	for _, d := range diffs {
+		if d.Canonical() {
+			return res, errors.New("canonical forms differ")
+		}
+	}
+	return res, nil
+}
+
+// canonDiffHash is the payload_hash a jcs envelope would carry for input.
+func canonDiffHash(path string, input []byte) *canonDiffSide {
+	side := &canonDiffSide{Path: path}
+	if _, err := canonical.Canonicalize(input); err != nil {
+		side.Error = err.Error()
+		return side
+	}
+	h, err := core.ComputePayloadHash(input, core.V1PayloadEncodingJCS)
+	if err != nil {
+		side.Error = err.Error()
+		return side
+	}
+	side.PayloadHash = h
+	return side
+}
+
+func printCanonDiffSide(w io.Writer, name string, side *canonDiffSide) {
+	if side.Error != "" {
+		fmt.Fprintf(w, "%s: %s\n  error: %s\n", name, side.Path, side.Error)
+		return
+	}
+	fmt.Fprintf(w, "%s: %s\n  payload_hash: %s\n", name, side.Path, side.PayloadHash)
+}
+
+func printDifference(w io.Writer, d canonical.Difference) {
+	ptr := d.Pointer
+	if ptr == "" {
+		ptr = "(root)"
+	}
+	switch d.Kind {
+	case canonical.DiffAdded:
+		fmt.Fprintf(w, "%s: added %s\n", ptr, d.B)
+	case canonical.DiffRemoved:
+		fmt.Fprintf(w, "%s: removed %s\n", ptr, d.A)
+	case canonical.DiffChanged:
+		fmt.Fprintf(w, "%s: changed %s -> %s\n", ptr, d.A, d.B)
+	case canonical.DiffDuplicateKey:
+		fmt.Fprintf(w, "%s: duplicate key (a: %s, b: %s occurrences)\n", ptr, orZero(d.A), orZero(d.B))
+	default:
+		fmt.Fprintf(w, "%s: %s %s -> %s (same canonical form)\n", ptr, d.Kind, d.A, d.B)
+	}
+}
+
+func orZero(s string) string {
+	if s == "" {
+		return "0"
+	}
+	return s
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/na0h/veriseal/core"
+	"github.com/na0h/veriseal/crypto"
+)
+
+type countersignResult struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+func runCountersign(args []string) error {
+	fs := flag.NewFlagSet("countersign", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	privPath := fs.String("privkey", "", "path to the witness ed25519 private key")
+	kid := fs.String("kid", "", "witness key id")
+	inPath := fs.String("input", "", "signed envelope JSON file to countersign")
+	outPath := fs.String("output", "", "output file path (default: stdout)")
+	jsonOut := fs.Bool("json", false, "output result as JSON (for CI / automation); when set, writes countersignature JSON to --output (required)")
+
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printCountersignUsage(os.Stdout)
+			return nil
+		}
+		printCountersignUsage(os.Stderr)
+		return err
+	}
+
+	if *privPath == "" {
+		printCountersignUsage(os.Stderr)
+		if *jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetEscapeHTML(false)
+			_ = enc.Encode(countersignResult{OK: false, Error: "missing --privkey"})
+		}
+		return fmt.Errorf("missing --privkey")
+	}
+	if *kid == "" {
+		printCountersignUsage(os.Stderr)
+		if *jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetEscapeHTML(false)
+			_ = enc.Encode(countersignResult{OK: false, Error: "missing --kid"})
+		}
+		return fmt.Errorf("missing --kid")
+	}
+	if *inPath == "" {
+		printCountersignUsage(os.Stderr)
+		if *jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetEscapeHTML(false)
+			_ = enc.Encode(countersignResult{OK: false, Error: "missing --input"})
+		}
+		return fmt.Errorf("missing --input")
+	}
+	if *jsonOut && *outPath == "" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		_ = enc.Encode(countersignResult{OK: false, Error: "missing --output (required when --json is set)"})
+		return fmt.Errorf("missing --output")
+	}
+
+	priv, err := crypto.LoadEd25519PrivateKey(*privPath)
+	if err != nil {
+		if *jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetEscapeHTML(false)
+			_ = enc.Encode(countersignResult{OK: false, Error: err.Error()})
+		}
+		return err
+	}
+
+	input, err := readInput(*inPath)
+	if err != nil {
+		if *jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetEscapeHTML(false)
+			_ = enc.Encode(countersignResult{OK: false, Error: err.Error()})
+		}
+		return err
+	}
+
+	counter, err := core.CountersignEd25519(input, *kid, priv)
+	if err != nil {
+		if *jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetEscapeHTML(false)
+			_ = enc.Encode(countersignResult{OK: false, Error: err.Error()})
+		}
+		return err
+	}
+
+	out, err := json.MarshalIndent(counter, "", "  ")
+	if err != nil {
+		if *jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetEscapeHTML(false)
+			_ = enc.Encode(countersignResult{OK: false, Error: err.Error()})
+		}
+		return err
+	}
+	out = append(out, '\n')
+
+	if *jsonOut {
+		if err := writeOutput(*outPath, out); err != nil {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetEscapeHTML(false)
+			_ = enc.Encode(countersignResult{OK: false, Error: err.Error()})
+			return err
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		_ = enc.Encode(countersignResult{OK: true})
+		return nil
+	}
+
+	return writeOutput(*outPath, out)
+}
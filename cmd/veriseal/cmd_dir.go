@@ -0,0 +1,243 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/na0h/veriseal/canonical"
+	"github.com/na0h/veriseal/core"
+	"github.com/na0h/veriseal/crypto"
+)
+
+type signDirResult struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+	Files int    `json:"files,omitempty"`
+}
+
+type verifyDirResult struct {
+	OK             bool              `json:"ok"`
+	SignatureOK    bool              `json:"signature_ok"`
+	Error          string            `json:"error,omitempty"`
+	SignatureError string            `json:"signature_error,omitempty"`
+	Files          int               `json:"files,omitempty"`
+	Skipped        int               `json:"skipped,omitempty"`
+	Changes        []core.FileChange `json:"changes,omitempty"`
+}
+
+func runSignDir(args []string) error {
+	fs := flag.NewFlagSet("sign-dir", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var ignore stringList
+	privPath := fs.String("privkey", "", "path to ed25519 private key")
+	kid := fs.String("kid", "", "key id of the signer")
+	dir := fs.String("dir", "", "directory to sign")
+	fs.Var(&ignore, "ignore", "path pattern to leave out (repeatable)")
+	outPath := fs.String("output", "", "output file path (default: stdout)")
+	jsonOut := fs.Bool("json", false, "output result as JSON (for CI / automation); when set, writes manifest envelope JSON to --output (required)")
+
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printSignDirUsage(os.Stdout)
+			return nil
+		}
+		printSignDirUsage(os.Stderr)
+		return err
+	}
+
+	var missing string
+	switch {
+	case *privPath == "":
+		missing = "--privkey"
+	case *kid == "":
+		missing = "--kid"
+	case *dir == "":
+		missing = "--dir"
+	}
+	if missing != "" {
+		printSignDirUsage(os.Stderr)
+		if *jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetEscapeHTML(false)
+			_ = enc.Encode(signDirResult{OK: false, Error: "missing " + missing})
+		}
+		return fmt.Errorf("missing %s", missing)
+	}
+	if *jsonOut && *outPath == "" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		_ = enc.Encode(signDirResult{OK: false, Error: "missing --output (required when --json is set)"})
+		return fmt.Errorf("missing --output")
+	}
+
+	out, files, err := signDir(*privPath, *kid, *dir, ignore)
+	if err == nil {
+		err = writeOutput(*outPath, out)
+	}
+	if *jsonOut {
+		res := signDirResult{OK: err == nil, Files: files}
+		if err != nil {
+			res.Error = err.Error()
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		_ = enc.Encode(res)
+	}
+	return err
+}
+
+func signDir(privPath, kid, dir string, ignore []string) ([]byte, int, error) {
+	priv, err := crypto.LoadEd25519PrivateKey(privPath)
+	if err != nil {
+		return nil, 0, err
+	}
+	m, err := core.BuildManifest(os.DirFS(dir), ignore)
+	if err != nil {
+		return nil, 0, err
+	}
+	signed, err := core.SignManifestEd25519(m, kid, priv)
+	if err != nil {
+		return nil, 0, err
+	}
+	out, err := marshalEnvelope(signed, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	// Indented, the envelope is larger than what SignManifestEd25519
+	// checked: make sure verify-dir still accepts it.
+	if err := canonical.CheckLimits(out, core.ManifestEnvelopeLimits); err != nil {
+		return nil, 0, fmt.Errorf("signed manifest is too large for verify-dir: %w", err)
+	}
+	return out, len(m.Files), nil
+}
+
+func runVerifyDir(args []string) error {
+	fs := flag.NewFlagSet("verify-dir", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var ignore stringList
+	pubPath := fs.String("pubkey", "", "path to ed25519 public key")
+	inPath := fs.String("input", "", "manifest envelope JSON file")
+	dir := fs.String("dir", "", "directory to verify")
+	fs.Var(&ignore, "ignore", "path pattern to leave out, on top of the signed ones (repeatable)")
+	allowSkipped := fs.Bool("allow-skipped", false, "accept signed files that --ignore leaves out (they are still listed)")
+	jsonOut := fs.Bool("json", false, "output result as JSON (for CI / automation)")
+
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printVerifyDirUsage(os.Stdout)
+			return nil
+		}
+		printVerifyDirUsage(os.Stderr)
+		return err
+	}
+
+	var missing string
+	switch {
+	case *pubPath == "":
+		missing = "--pubkey"
+	case *inPath == "":
+		missing = "--input"
+	case *dir == "":
+		missing = "--dir"
+	}
+	if missing != "" {
+		printVerifyDirUsage(os.Stderr)
+		return fmt.Errorf("missing %s", missing)
+	}
+
+	pub, err := crypto.LoadEd25519PublicKey(*pubPath)
+	if err != nil {
+		return err
+	}
+	input, err := readInput(*inPath)
+	if err != nil {
+		return err
+	}
+
+	res := verifyDirResult{}
+
+	envelope, err := core.VerifyEd25519JSONWithLimits(input, pub, core.ManifestEnvelopeLimits)
+	if err != nil {
+		res.SignatureError = err.Error()
+	} else {
+		res.SignatureOK = true
+	}
+
+	if res.SignatureOK {
+		m, err := core.EnvelopeManifest(envelope)
+		if err == nil {
+			res.Files = len(m.Files)
+			res.Changes, err = core.VerifyManifest(m, os.DirFS(*dir), ignore)
+		}
+		for _, c := range res.Changes {
+			if c.Change == core.FileSkipped {
+				res.Skipped++
+			}
+		}
+		switch {
+		case err != nil:
+			res.Error = err.Error()
+		case len(res.Changes) > res.Skipped:
+			res.Error = fmt.Sprintf("files differ from the manifest (%d)", len(res.Changes)-res.Skipped)
+		case res.Skipped > 0 && !*allowSkipped:
+			res.Error = fmt.Sprintf("signed files left out by --ignore (%d); pass --allow-skipped to accept", res.Skipped)
+		}
+	}
+
+	res.OK = res.SignatureOK && res.Error == ""
+	if !res.SignatureOK {
+		res.Error = res.SignatureError
+	}
+
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(res); err != nil {
+			return err
+		}
+		if !res.OK {
+			return errors.New(res.Error)
+		}
+		return nil
+	}
+
+	// Human-readable output
+	if res.SignatureOK {
+		fmt.Fprintln(os.Stdout, "Verify signed: OK")
+	} else {
+		fmt.Fprintln(os.Stdout, "Verify signed: FAILED")
+		fmt.Fprintln(os.Stdout, "  reason:", res.SignatureError)
+	}
+	if res.SignatureOK {
+		switch {
+		case res.OK && res.Skipped > 0:
+			fmt.Fprintf(os.Stdout, "Verify files: OK (%d files, %d not checked)\n", res.Files, res.Skipped)
+		case res.OK:
+			fmt.Fprintf(os.Stdout, "Verify files: OK (%d files)\n", res.Files)
+		default:
+			fmt.Fprintln(os.Stdout, "Verify files: FAILED")
+			if len(res.Changes) == res.Skipped {
+				fmt.Fprintln(os.Stdout, "  reason:", res.Error)
+			}
+		}
+	}
+	for _, c := range res.Changes {
+		if len(c.Fields) > 0 {
+			fmt.Fprintf(os.Stdout, "  %s: %s (%s)\n", c.Change, c.Path, strings.Join(c.Fields, ", "))
+		} else {
+			fmt.Fprintf(os.Stdout, "  %s: %s\n", c.Change, c.Path)
+		}
+	}
+
+	if !res.OK {
+		return errors.New(res.Error)
+	}
+	return nil
+}
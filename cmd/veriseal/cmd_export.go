@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/na0h/veriseal/core"
+	"github.com/na0h/veriseal/crypto"
+)
+
+const (
+	formatJSON    = "json"
+	formatJWS     = "jws"
+	formatJWSJSON = "jws-json"
+	formatCOSE    = "cose"
+	formatDSSE    = "dsse"
+	formatCBOR    = "cbor"
+	formatYAML    = "yaml"
+)
+
+type exportResult struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	format := fs.String("format", "", "output format: jws, jws-json, cose or dsse")
+	privPath := fs.String("privkey", "", "path to the ed25519 private key the envelope was signed with")
+	inPath := fs.String("input", "", "signed envelope JSON file")
+	payloadFile := fs.String("payload-file", "", "payload file path (default: the attached payload)")
+	payloadType := fs.String("payload-type", "", "DSSE payloadType (default: by payload_encoding)")
+	outPath := fs.String("output", "", "output file path (default: stdout)")
+	jsonOut := fs.Bool("json", false, "output result as JSON (for CI / automation); when set, writes the exported data to --output (required)")
+
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printExportUsage(os.Stdout)
+			return nil
+		}
+		printExportUsage(os.Stderr)
+		return err
+	}
+
+	var missing string
+	switch {
+	case *format == "":
+		missing = "--format"
+	case *privPath == "":
+		missing = "--privkey"
+	case *inPath == "":
+		missing = "--input"
+	}
+	if missing != "" {
+		printExportUsage(os.Stderr)
+		if *jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetEscapeHTML(false)
+			_ = enc.Encode(exportResult{OK: false, Error: "missing " + missing})
+		}
+		return fmt.Errorf("missing %s", missing)
+	}
+	if *jsonOut && *outPath == "" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		_ = enc.Encode(exportResult{OK: false, Error: "missing --output (required when --json is set)"})
+		return fmt.Errorf("missing --output")
+	}
+
+	out, err := exportEnvelope(*format, *inPath, *payloadFile, *privPath, *payloadType)
+	if err != nil {
+		if *jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetEscapeHTML(false)
+			_ = enc.Encode(exportResult{OK: false, Error: err.Error()})
+		}
+		return err
+	}
+
+	if *jsonOut {
+		if err := writeOutput(*outPath, out); err != nil {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetEscapeHTML(false)
+			_ = enc.Encode(exportResult{OK: false, Error: err.Error()})
+			return err
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		_ = enc.Encode(exportResult{OK: true})
+		return nil
+	}
+
+	return writeOutput(*outPath, out)
+}
+
+func exportEnvelope(format, inPath, payloadFile, privPath, payloadType string) ([]byte, error) {
+	priv, err := crypto.LoadEd25519PrivateKey(privPath)
+	if err != nil {
+		return nil, err
+	}
+
+	input, err := readInput(inPath)
+	if err != nil {
+		return nil, err
+	}
+	envelope, err := core.ParseEnvelopeStrict(input)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case formatJWS, formatJWSJSON:
+		payloadBytes, err := loadPayload(envelope, payloadFile)
+		if err != nil {
+			return nil, err
+		}
+		j, err := core.ExportJWS(envelope, payloadBytes, priv)
+		if err != nil {
+			return nil, err
+		}
+		if format == formatJWS {
+			return []byte(j.Compact() + "\n"), nil
+		}
+		out, err := json.MarshalIndent(j, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(out, '\n'), nil
+	case formatCOSE:
+		return core.ExportCOSE(envelope, priv)
+	case formatDSSE:
+		payloadBytes, err := loadPayload(envelope, payloadFile)
+		if err != nil {
+			return nil, err
+		}
+		d, err := core.ExportDSSE(envelope, payloadBytes, priv, payloadType)
+		if err != nil {
+			return nil, err
+		}
+		out, err := json.MarshalIndent(d, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(out, '\n'), nil
+	default:
+		return nil, fmt.Errorf("unsupported --format: %s", format)
+	}
+}
+
+// loadPayload reads payloadFile, or falls back to the attached payload.
+func loadPayload(envelope core.Envelope, payloadFile string) ([]byte, error) {
+	if payloadFile != "" {
+		return os.ReadFile(payloadFile)
+	}
+	if len(envelope.Payload) > 0 {
+		return core.AttachedPayload(envelope)
+	}
+	return nil, fmt.Errorf("missing --payload-file (envelope has no attached payload)")
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/na0h/veriseal/core"
+)
+
+func runExtract(args []string) error {
+	fs := flag.NewFlagSet("extract", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	inPath := fs.String("input", "", "envelope JSON file with an attached payload")
+	outPath := fs.String("output", "", "output file path (default: stdout)")
+
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printExtractUsage(os.Stdout)
+			return nil
+		}
+		printExtractUsage(os.Stderr)
+		return err
+	}
+
+	if *inPath == "" {
+		printExtractUsage(os.Stderr)
+		return fmt.Errorf("missing --input")
+	}
+
+	input, err := readInput(*inPath)
+	if err != nil {
+		return err
+	}
+
+	var envelope core.Envelope
+	if err := json.Unmarshal(input, &envelope); err != nil {
+		return err
+	}
+
+	// Written as-is: no trailing newline, so the output hashes to payload_hash.
+	out, err := core.AttachedPayload(envelope)
+	if err != nil {
+		return err
+	}
+	return writeOutput(*outPath, out)
+}
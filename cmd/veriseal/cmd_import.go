@@ -0,0 +1,155 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/na0h/veriseal/core"
+	"github.com/na0h/veriseal/crypto"
+)
+
+type importResult struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	format := fs.String("format", "", "input format: jws (compact or JSON serialization), cose or dsse")
+	inPath := fs.String("input", "", "input file path (default: stdin)")
+	pubPath := fs.String("pubkey", "", "path to ed25519 public key; verifies the imported signature first (optional)")
+	outPath := fs.String("output", "", "output envelope file path (default: stdout)")
+	payloadOut := fs.String("payload-output", "", "write the carried payload to this file (optional)")
+	kid := fs.String("kid", "", "kid of the imported envelope (dsse only; default: keyid of the first signature)")
+	attach := fs.Bool("attach", false, "embed the carried payload in the output envelope")
+	jsonOut := fs.Bool("json", false, "output result as JSON (for CI / automation); when set, writes envelope JSON to --output (required)")
+
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printImportUsage(os.Stdout)
+			return nil
+		}
+		printImportUsage(os.Stderr)
+		return err
+	}
+
+	if *format == "" {
+		printImportUsage(os.Stderr)
+		if *jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetEscapeHTML(false)
+			_ = enc.Encode(importResult{OK: false, Error: "missing --format"})
+		}
+		return fmt.Errorf("missing --format")
+	}
+	if *jsonOut && *outPath == "" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		_ = enc.Encode(importResult{OK: false, Error: "missing --output (required when --json is set)"})
+		return fmt.Errorf("missing --output")
+	}
+
+	out, payload, err := importEnvelope(*format, *inPath, *pubPath, *kid, *attach)
+	if err == nil && *payloadOut != "" && payload == nil {
+		err = fmt.Errorf("%s input does not carry the payload", *format)
+	}
+	if err == nil && *payloadOut != "" {
+		err = writeOutput(*payloadOut, payload)
+	}
+	if err != nil {
+		if *jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetEscapeHTML(false)
+			_ = enc.Encode(importResult{OK: false, Error: err.Error()})
+		}
+		return err
+	}
+
+	if *jsonOut {
+		if err := writeOutput(*outPath, out); err != nil {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetEscapeHTML(false)
+			_ = enc.Encode(importResult{OK: false, Error: err.Error()})
+			return err
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		_ = enc.Encode(importResult{OK: true})
+		return nil
+	}
+
+	return writeOutput(*outPath, out)
+}
+
+func importEnvelope(format, inPath, pubPath, kid string, attach bool) ([]byte, []byte, error) {
+	var pub ed25519.PublicKey
+	if pubPath != "" {
+		var err error
+		pub, err = crypto.LoadEd25519PublicKey(pubPath)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	input, err := readInput(inPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var envelope core.Envelope
+	var payload []byte
+	switch format {
+	case formatJWS, formatJWSJSON:
+		j, err := core.ParseJWS(input)
+		if err != nil {
+			return nil, nil, err
+		}
+		envelope, payload, err = core.ImportJWS(j, pub)
+		if err != nil {
+			return nil, nil, err
+		}
+	case formatCOSE:
+		if attach {
+			return nil, nil, fmt.Errorf("cose input does not carry the payload")
+		}
+		envelope, err = core.ImportCOSE(input, pub)
+		if err != nil {
+			return nil, nil, err
+		}
+		if envelope.Sig == nil {
+			fmt.Fprintln(os.Stderr, "WARN: cose message carries no envelope signature; the imported envelope is unsigned")
+		}
+	case formatDSSE:
+		d, err := core.ParseDSSE(input)
+		if err != nil {
+			return nil, nil, err
+		}
+		envelope, payload, err = core.ImportDSSE(d, kid, pub)
+		if err != nil {
+			return nil, nil, err
+		}
+		fmt.Fprintln(os.Stderr, "WARN: dsse carries no envelope signature; the imported envelope is unsigned")
+	default:
+		return nil, nil, fmt.Errorf("unsupported --format: %s", format)
+	}
+
+	if attach {
+		envelope, err = core.AttachPayload(envelope, payload)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	out, err := json.MarshalIndent(envelope, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(out, '\n'), payload, nil
+}
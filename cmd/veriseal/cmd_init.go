@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"flag"
+	"fmt"
 	"io"
 	"os"
 
@@ -20,7 +21,12 @@ func runInit(args []string) error {
 	fs.SetOutput(io.Discard)
 
 	kid := fs.String("kid", "", "key id")
-	payloadEncoding := fs.String("payload-encoding", core.V1PayloadEncodingJCS, "payload encoding: jcs or raw")
+	payloadEncoding := fs.String("payload-encoding", core.V1PayloadEncodingJCS, "payload encoding: jcs, raw, text, cbor, yaml, ndjson, sd-jcs, tar or zip")
+	var include, exclude stringList
+	fs.Var(&include, "include", "jcs: JSON pointer of a value covered by payload_hash (repeatable)")
+	fs.Var(&exclude, "exclude", "jcs: JSON pointer of a value left out of payload_hash (repeatable)")
+	trimWS := fs.Bool("trim-trailing-whitespace", false, "payload_encoding=text: also trim trailing whitespace from every line")
+	version := fs.Int("version", core.Version1, "envelope version: 1 or 2")
 	outPath := fs.String("output", "", "output file path (default: stdout)")
 	jsonOut := fs.Bool("json", false, "output result as JSON (for CI / automation); when set, writes envelope JSON to --output (required)")
 
@@ -48,7 +54,28 @@ func runInit(args []string) error {
 		return errors.New("missing --output")
 	}
 
-	env, err := core.NewEnvelopeTemplateV1(*kid, *payloadEncoding)
+	var env core.Envelope
+	var err error
+	switch *version {
+	case core.Version1:
+		env, err = core.NewEnvelopeTemplateV1(*kid, *payloadEncoding)
+	case core.Version2:
+		env, err = core.NewEnvelopeTemplateV2(*kid, *payloadEncoding)
+	default:
+		err = fmt.Errorf("unsupported --version: %d (supported: %v)", *version, core.SupportedVersions)
+	}
+	if err == nil && *trimWS {
+		if env.PayloadText == nil {
+			err = errors.New("--trim-trailing-whitespace requires --payload-encoding text")
+		} else {
+			env.PayloadText.TrimTrailingWhitespace = true
+		}
+	}
+	if err == nil && (len(include) > 0 || len(exclude) > 0) {
+		env.PayloadInclude = include
+		env.PayloadExclude = exclude
+		err = core.ValidateEnvelope(env)
+	}
 	if err != nil {
 		if *jsonOut {
 			enc := json.NewEncoder(os.Stdout)
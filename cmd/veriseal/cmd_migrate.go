@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/na0h/veriseal/core"
+	"github.com/na0h/veriseal/crypto"
+)
+
+type migrateResult struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+func runMigrate(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	privPath := fs.String("privkey", "", "path to ed25519 private key for the v2 envelope")
+	kid := fs.String("kid", "", "key id for the v2 envelope")
+	inPath := fs.String("input", "", "signed v1 envelope JSON file")
+	pubPath := fs.String("pubkey", "", "public key of the original signer; verifies the v1 envelope first (optional)")
+	mode := fs.String("mode", core.MigrateModeResign, "migration mode: resign or wrap")
+	outPath := fs.String("output", "", "output file path (default: stdout)")
+	jsonOut := fs.Bool("json", false, "output result as JSON (for CI / automation); when set, writes v2 envelope JSON to --output (required)")
+
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printMigrateUsage(os.Stdout)
+			return nil
+		}
+		printMigrateUsage(os.Stderr)
+		return err
+	}
+
+	if *privPath == "" {
+		printMigrateUsage(os.Stderr)
+		if *jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetEscapeHTML(false)
+			_ = enc.Encode(migrateResult{OK: false, Error: "missing --privkey"})
+		}
+		return fmt.Errorf("missing --privkey")
+	}
+	if *kid == "" {
+		printMigrateUsage(os.Stderr)
+		if *jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetEscapeHTML(false)
+			_ = enc.Encode(migrateResult{OK: false, Error: "missing --kid"})
+		}
+		return fmt.Errorf("missing --kid")
+	}
+	if *inPath == "" {
+		printMigrateUsage(os.Stderr)
+		if *jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetEscapeHTML(false)
+			_ = enc.Encode(migrateResult{OK: false, Error: "missing --input"})
+		}
+		return fmt.Errorf("missing --input")
+	}
+	if *jsonOut && *outPath == "" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		_ = enc.Encode(migrateResult{OK: false, Error: "missing --output (required when --json is set)"})
+		return fmt.Errorf("missing --output")
+	}
+
+	out, err := migrateEnvelope(*inPath, *pubPath, *privPath, *kid, *mode)
+	if err != nil {
+		if *jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetEscapeHTML(false)
+			_ = enc.Encode(migrateResult{OK: false, Error: err.Error()})
+		}
+		return err
+	}
+
+	if *jsonOut {
+		if err := writeOutput(*outPath, out); err != nil {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetEscapeHTML(false)
+			_ = enc.Encode(migrateResult{OK: false, Error: err.Error()})
+			return err
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		_ = enc.Encode(migrateResult{OK: true})
+		return nil
+	}
+
+	return writeOutput(*outPath, out)
+}
+
+func migrateEnvelope(inPath, pubPath, privPath, kid, mode string) ([]byte, error) {
+	priv, err := crypto.LoadEd25519PrivateKey(privPath)
+	if err != nil {
+		return nil, err
+	}
+
+	input, err := readInput(inPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if pubPath != "" {
+		pub, err := crypto.LoadEd25519PublicKey(pubPath)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := core.VerifyEd25519JSON(input, pub); err != nil {
+			return nil, fmt.Errorf("original: %w", err)
+		}
+	}
+
+	migrated, err := core.MigrateV1ToV2Ed25519(input, mode, kid, priv)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := json.MarshalIndent(migrated, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
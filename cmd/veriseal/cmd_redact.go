@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/na0h/veriseal/core"
+)
+
+func runRedact(args []string) error {
+	fs := flag.NewFlagSet("redact", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var fields stringList
+	inPath := fs.String("input", "", "signed sd-jcs envelope JSON file")
+	outPath := fs.String("output", "", "output file path (default: stdout)")
+	fs.Var(&fields, "field", "JSON pointer of a field to hide, with everything below it (repeatable)")
+
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printRedactUsage(os.Stdout)
+			return nil
+		}
+		printRedactUsage(os.Stderr)
+		return err
+	}
+
+	if *inPath == "" {
+		printRedactUsage(os.Stderr)
+		return fmt.Errorf("missing --input")
+	}
+	if len(fields) == 0 {
+		printRedactUsage(os.Stderr)
+		return fmt.Errorf("missing --field")
+	}
+
+	input, err := readInput(*inPath)
+	if err != nil {
+		return err
+	}
+	out, err := redactEnvelope(input, fields)
+	if err != nil {
+		return err
+	}
+	return writeOutput(*outPath, out)
+}
+
+// redactEnvelope removes disclosures from the attached sd payload. Only the
+// unsigned payload member changes, so every signature stays valid.
+func redactEnvelope(input []byte, fields []string) ([]byte, error) {
+	envelope, err := core.ParseEnvelopeStrict(input)
+	if err != nil {
+		return nil, err
+	}
+	if envelope.PayloadEncoding != core.V1PayloadEncodingSD {
+		return nil, fmt.Errorf("redact requires payload_encoding=%s (got %s)", core.V1PayloadEncodingSD, envelope.PayloadEncoding)
+	}
+	payload, err := core.AttachedPayload(envelope)
+	if err != nil {
+		return nil, err
+	}
+	payload, err = core.RedactSDPayload(payload, fields)
+	if err != nil {
+		return nil, err
+	}
+	b, err := setTemplateField(input, "payload", json.RawMessage(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, b, "", "  "); err != nil {
+		return nil, err
+	}
+	out.WriteByte('\n')
+	return out.Bytes(), nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"crypto/ed25519"
 	"encoding/json"
 	"errors"
 	"flag"
@@ -26,9 +27,18 @@ func runSign(args []string) error {
 	outPath := fs.String("output", "", "output file path (default: stdout)")
 	payloadFile := fs.String("payload-file", "", "payload file path")
 	setIat := fs.Bool("set-iat", false, "set iat (epoch seconds) right before signing")
+	allowNonIJSON := fs.Bool("allow-non-ijson", false, "sign jcs / ndjson payloads that are not I-JSON (duplicate keys, lone surrogates, integers beyond 2^53-1)")
+	recordHashes := fs.Bool("record-hashes", false, "ndjson, tar and zip only: set payload_record_hashes / payload_entry_hashes for 'verify --explain'")
+	attach := fs.Bool("attach", false, "embed the payload in the signed envelope")
+	appendSig := fs.Bool("append-signature", false, "add a signature to an already signed envelope (requires --kid)")
+	kid := fs.String("kid", "", "key id of the appended signer, or of the sidecar template")
+	format := fs.String("format", formatJSON, "output format: json or cose")
+	payloadRef := fs.String("payload-ref", "", "URI of the payload to record (signed) as payload_ref")
+	payloadEnc := fs.String("payload-encoding", "", "payload encoding of the sidecar template when --input is not set (default: jcs for .json, cbor for .cbor, yaml for .yaml/.yml, ndjson for .ndjson/.jsonl, tar for .tar/.tgz/.tar.gz, zip for .zip, else raw)")
 	jsonOut := fs.Bool("json", false, "output result as JSON (for CI / automation); when set, writes signed envelope JSON to --output (required)")
 
-	if err := parseFlags(fs, args); err != nil {
+	positional, err := parseFlagsAndArgs(fs, args)
+	if err != nil {
 		if errors.Is(err, flag.ErrHelp) {
 			printSignUsage(os.Stdout)
 			return nil
@@ -36,6 +46,28 @@ func runSign(args []string) error {
 		printSignUsage(os.Stderr)
 		return err
 	}
+	if len(positional) > 1 {
+		printSignUsage(os.Stderr)
+		return fmt.Errorf("too many arguments: %v", positional)
+	}
+
+	// sidecar: 'sign <file>' signs <file> into <file>.vseal
+	sidecar := len(positional) == 1
+	if sidecar {
+		if *payloadFile != "" || *appendSig || *format != formatJSON {
+			printSignUsage(os.Stderr)
+			if *jsonOut {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetEscapeHTML(false)
+				_ = enc.Encode(signResult{OK: false, Error: "a payload file argument cannot be used with --payload-file, --append-signature or --format"})
+			}
+			return fmt.Errorf("a payload file argument cannot be used with --payload-file, --append-signature or --format")
+		}
+		*payloadFile = positional[0]
+		if *outPath == "" {
+			*outPath = sidecarPath(positional[0])
+		}
+	}
 
 	if *privPath == "" {
 		printSignUsage(os.Stderr)
@@ -46,7 +78,7 @@ func runSign(args []string) error {
 		}
 		return fmt.Errorf("missing --privkey")
 	}
-	if *inPath == "" {
+	if *inPath == "" && !sidecar {
 		printSignUsage(os.Stderr)
 		if *jsonOut {
 			enc := json.NewEncoder(os.Stdout)
@@ -55,7 +87,7 @@ func runSign(args []string) error {
 		}
 		return fmt.Errorf("missing --input")
 	}
-	if *payloadFile == "" {
+	if *payloadFile == "" && !*appendSig {
 		printSignUsage(os.Stderr)
 		if *jsonOut {
 			enc := json.NewEncoder(os.Stdout)
@@ -64,45 +96,78 @@ func runSign(args []string) error {
 		}
 		return fmt.Errorf("missing --payload-file")
 	}
-	if *jsonOut && *outPath == "" {
-		enc := json.NewEncoder(os.Stdout)
-		enc.SetEscapeHTML(false)
-		_ = enc.Encode(signResult{OK: false, Error: "missing --output (required when --json is set)"})
-		return fmt.Errorf("missing --output")
+	if *appendSig && *attach {
+		printSignUsage(os.Stderr)
+		if *jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetEscapeHTML(false)
+			_ = enc.Encode(signResult{OK: false, Error: "--attach cannot be used with --append-signature"})
+		}
+		return fmt.Errorf("--attach cannot be used with --append-signature")
 	}
-
-	priv, err := crypto.LoadEd25519PrivateKey(*privPath)
-	if err != nil {
+	if *appendSig && *setIat {
+		printSignUsage(os.Stderr)
 		if *jsonOut {
 			enc := json.NewEncoder(os.Stdout)
 			enc.SetEscapeHTML(false)
-			_ = enc.Encode(signResult{OK: false, Error: err.Error()})
+			_ = enc.Encode(signResult{OK: false, Error: "--set-iat cannot be used with --append-signature"})
 		}
-		return err
+		return fmt.Errorf("--set-iat cannot be used with --append-signature")
 	}
-
-	input, err := readInput(*inPath)
-	if err != nil {
+	if *format != formatJSON && *format != formatCOSE {
+		printSignUsage(os.Stderr)
 		if *jsonOut {
 			enc := json.NewEncoder(os.Stdout)
 			enc.SetEscapeHTML(false)
-			_ = enc.Encode(signResult{OK: false, Error: err.Error()})
+			_ = enc.Encode(signResult{OK: false, Error: "unsupported --format: " + *format})
 		}
-		return err
+		return fmt.Errorf("unsupported --format: %s", *format)
 	}
-
-	payloadBytes, err := os.ReadFile(*payloadFile)
-	if err != nil {
+	if *format == formatCOSE && (*appendSig || *attach) {
+		printSignUsage(os.Stderr)
 		if *jsonOut {
 			enc := json.NewEncoder(os.Stdout)
 			enc.SetEscapeHTML(false)
-			_ = enc.Encode(signResult{OK: false, Error: err.Error()})
+			_ = enc.Encode(signResult{OK: false, Error: "--format cose cannot be used with --attach or --append-signature"})
 		}
-		return err
+		return fmt.Errorf("--format cose cannot be used with --attach or --append-signature")
+	}
+	if *payloadEnc != "" && (!sidecar || *inPath != "") {
+		printSignUsage(os.Stderr)
+		if *jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetEscapeHTML(false)
+			_ = enc.Encode(signResult{OK: false, Error: "--payload-encoding only applies to 'sign <file>' without --input"})
+		}
+		return fmt.Errorf("--payload-encoding only applies to 'sign <file>' without --input")
+	}
+	if *payloadRef != "" && *appendSig {
+		printSignUsage(os.Stderr)
+		if *jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetEscapeHTML(false)
+			_ = enc.Encode(signResult{OK: false, Error: "--payload-ref cannot be used with --append-signature"})
+		}
+		return fmt.Errorf("--payload-ref cannot be used with --append-signature")
+	}
+	if *appendSig && *kid == "" {
+		printSignUsage(os.Stderr)
+		if *jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetEscapeHTML(false)
+			_ = enc.Encode(signResult{OK: false, Error: "missing --kid (required when --append-signature is set)"})
+		}
+		return fmt.Errorf("missing --kid")
+	}
+	if *jsonOut && *outPath == "" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		_ = enc.Encode(signResult{OK: false, Error: "missing --output (required when --json is set)"})
+		return fmt.Errorf("missing --output")
 	}
 
-	var envelope core.Envelope
-	if err := json.Unmarshal(input, &envelope); err != nil {
+	priv, err := crypto.LoadEd25519PrivateKey(*privPath)
+	if err != nil {
 		if *jsonOut {
 			enc := json.NewEncoder(os.Stdout)
 			enc.SetEscapeHTML(false)
@@ -111,7 +176,12 @@ func runSign(args []string) error {
 		return err
 	}
 
-	signed, err := core.SignEd25519(envelope, payloadBytes, priv, *setIat)
+	var input []byte
+	if sidecar && *inPath == "" {
+		input, err = sidecarTemplate(*payloadFile, *kid, *payloadEnc)
+	} else {
+		input, err = readInput(*inPath)
+	}
 	if err != nil {
 		if *jsonOut {
 			enc := json.NewEncoder(os.Stdout)
@@ -121,17 +191,28 @@ func runSign(args []string) error {
 		return err
 	}
 
-	if *setIat && envelope.Iat != nil {
-		old := *envelope.Iat
-		fmt.Fprintf(
-			os.Stderr,
-			"WARN: iat overwritten (old=%d, new=%d)\n",
-			old,
-			*signed.Iat,
-		)
+	if *payloadRef != "" {
+		input, err = setTemplateField(input, "payload_ref", *payloadRef)
+		if err != nil {
+			if *jsonOut {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetEscapeHTML(false)
+				_ = enc.Encode(signResult{OK: false, Error: err.Error()})
+			}
+			return err
+		}
 	}
 
-	out, err := json.MarshalIndent(signed, "", "  ")
+	opts := core.SignOptions{SetIat: *setIat, AllowNonIJSON: *allowNonIJSON, RecordHashes: *recordHashes}
+	var out []byte
+	switch {
+	case *format == formatCOSE:
+		out, err = signCOSE(input, *payloadFile, priv, opts)
+	case *appendSig:
+		out, err = marshalEnvelope(appendSignature(input, *payloadFile, *kid, priv))
+	default:
+		out, err = marshalEnvelope(signEnvelope(input, *payloadFile, priv, opts, *attach))
+	}
 	if err != nil {
 		if *jsonOut {
 			enc := json.NewEncoder(os.Stdout)
@@ -140,7 +221,6 @@ func runSign(args []string) error {
 		}
 		return err
 	}
-	out = append(out, '\n')
 
 	if *jsonOut {
 		if err := writeOutput(*outPath, out); err != nil {
@@ -157,3 +237,123 @@ func runSign(args []string) error {
 
 	return writeOutput(*outPath, out)
 }
+
+func signEnvelope(input []byte, payloadFile string, priv ed25519.PrivateKey, opts core.SignOptions, attach bool) (core.Envelope, error) {
+	var envelope core.Envelope
+	if err := json.Unmarshal(input, &envelope); err != nil {
+		return core.Envelope{}, err
+	}
+
+	var signed core.Envelope
+	var payloadBytes []byte
+	var err error
+	if attach || envelope.PayloadEncoding == core.V1PayloadEncodingSD {
+		payloadBytes, err = os.ReadFile(payloadFile)
+		if err != nil {
+			return core.Envelope{}, err
+		}
+		// sd-jcs: salt every field and attach the result; the holder needs
+		// the salts to disclose fields later.
+		if envelope.PayloadEncoding == core.V1PayloadEncodingSD {
+			payloadBytes, err = core.MakeSDPayload(payloadBytes)
+			if err != nil {
+				return core.Envelope{}, err
+			}
+			attach = true
+		}
+		signed, err = core.SignEd25519WithOptions(envelope, payloadBytes, priv, opts)
+	} else {
+		// Detached: hash the file as it is read, so large raw payloads
+		// never have to fit in memory.
+		signed, err = signFile(envelope, payloadFile, priv, opts)
+	}
+	if err != nil {
+		return core.Envelope{}, err
+	}
+
+	if opts.SetIat && envelope.Iat != nil {
+		old := *envelope.Iat
+		fmt.Fprintf(
+			os.Stderr,
+			"WARN: iat overwritten (old=%d, new=%d)\n",
+			old,
+			*signed.Iat,
+		)
+	}
+
+	if attach {
+		return core.AttachPayload(signed, payloadBytes)
+	}
+	return signed, nil
+}
+
+func signFile(envelope core.Envelope, payloadFile string, priv ed25519.PrivateKey, opts core.SignOptions) (core.Envelope, error) {
+	f, err := os.Open(payloadFile)
+	if err != nil {
+		return core.Envelope{}, err
+	}
+	defer f.Close() //nolint:errcheck
+	return core.SignReader(envelope, f, priv, opts)
+}
+
+// signCOSE signs the envelope template as a COSE_Sign1 message.
+func signCOSE(input []byte, payloadFile string, priv ed25519.PrivateKey, opts core.SignOptions) ([]byte, error) {
+	payloadBytes, err := os.ReadFile(payloadFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var envelope core.Envelope
+	if err := json.Unmarshal(input, &envelope); err != nil {
+		return nil, err
+	}
+	if envelope.PayloadEncoding == core.V1PayloadEncodingSD {
+		return nil, fmt.Errorf("payload_encoding=sd-jcs cannot be signed as cose (the salted payload must be attached)")
+	}
+
+	return core.SignCOSEWithOptions(envelope, payloadBytes, priv, opts)
+}
+
+// setTemplateField sets one member of the envelope template JSON, keeping
+// the others as they are.
+func setTemplateField(input []byte, name string, value any) ([]byte, error) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(input, &obj); err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	obj[name] = b
+	return json.Marshal(obj)
+}
+
+// marshalEnvelope formats a signed envelope for output.
+func marshalEnvelope(envelope core.Envelope, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	out, err := json.MarshalIndent(envelope, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// appendSignature adds a signer to a signed envelope. When a payload file is
+// given, payload_hash is checked first so the signer knows what they approve.
+func appendSignature(input []byte, payloadFile string, kid string, priv ed25519.PrivateKey) (core.Envelope, error) {
+	envelope, err := core.ParseEnvelopeStrict(input)
+	if err != nil {
+		return core.Envelope{}, err
+	}
+
+	if payloadFile != "" {
+		if err := verifyPayloadFile(envelope, payloadFile); err != nil {
+			return core.Envelope{}, err
+		}
+	}
+
+	return core.AppendSignatureEd25519(envelope, kid, priv)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/na0h/veriseal/canonical"
+	"github.com/na0h/veriseal/core"
+)
+
+func writePEM(t *testing.T, path, typ string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// A jcs payload larger than canonical.DefaultLimits.MaxSize cannot be
+// canonicalized in memory: sign and verify must stream it, I-JSON checks
+// included. The limit is lowered so that the payload stays small.
+func TestSign_JCSPayloadAboveMaxSize_Streams(t *testing.T) {
+	saved := canonical.DefaultLimits
+	canonical.DefaultLimits.MaxSize = 1 << 20
+	t.Cleanup(func() { canonical.DefaultLimits = saved })
+	dir := t.TempDir()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privPath := filepath.Join(dir, "priv.pem")
+	pubPath := filepath.Join(dir, "pub.pem")
+	writePEM(t, privPath, "PRIVATE KEY", privDER)
+	writePEM(t, pubPath, "PUBLIC KEY", pubDER)
+
+	tpl, err := core.NewEnvelopeTemplateV1("demo-1", core.V1PayloadEncodingJCS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(tpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tplPath := filepath.Join(dir, "envelope.json")
+	if err := os.WriteFile(tplPath, b, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var payload bytes.Buffer
+	payload.WriteString("[")
+	for i := 0; int64(payload.Len()) <= canonical.DefaultLimits.MaxSize; i++ {
+		if i > 0 {
+			payload.WriteString(",")
+		}
+		fmt.Fprintf(&payload, `{"name":"record-%d","id":%d}`, i, i)
+	}
+	payload.WriteString("]")
+	payloadPath := filepath.Join(dir, "payload.json")
+	if err := os.WriteFile(payloadPath, payload.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := canonical.Canonicalize(payload.Bytes()); !errors.Is(err, canonical.ErrTooLarge) {
+		t.Fatalf("payload fits in memory: %v", err)
+	}
+
+	signedPath := filepath.Join(dir, "envelope.signed.json")
+	if err := runSign([]string{"--privkey", privPath, "--input", tplPath, "--payload-file", payloadPath, "--output", signedPath}); err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if err := runVerify([]string{"--pubkey", pubPath, "--input", signedPath, "--payload-file", payloadPath}); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+
+	// not I-JSON: still rejected while streaming
+	dup := append(bytes.TrimSuffix(payload.Bytes(), []byte("]")), `,{"id":1,"id":2}]`...)
+	if err := os.WriteFile(payloadPath, dup, 0600); err != nil {
+		t.Fatal(err)
+	}
+	err = runSign([]string{"--privkey", privPath, "--input", tplPath, "--payload-file", payloadPath, "--output", signedPath})
+	if !errors.Is(err, canonical.ErrNotIJSON) {
+		t.Fatalf("want ErrNotIJSON, got %v", err)
+	}
+}
@@ -8,9 +8,11 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"strings"
 
+	"github.com/na0h/veriseal/canonical"
 	"github.com/na0h/veriseal/core"
 )
 
@@ -38,6 +40,8 @@ func runTSAudit(args []string) error {
 
 	inPath := fs.String("input", "", "input JSONL file (signed envelopes)")
 	strictStart := fs.Bool("strict-start", false, "require ts_seq=0 and empty ts_prev on the first line")
+	lenient := fs.Bool("lenient", false, "accept unknown, duplicate and case-variant fields (legacy parsing)")
+	applyLimits := limitFlags(fs)
 	jsonOut := fs.Bool("json", false, "output result as JSON")
 
 	if err := parseFlags(fs, args); err != nil {
@@ -52,6 +56,7 @@ func runTSAudit(args []string) error {
 		printTSAuditUsage(os.Stderr)
 		return errors.New("missing --input")
 	}
+	limits := applyLimits()
 
 	var r io.Reader
 	if *inPath == "-" {
@@ -65,9 +70,14 @@ func runTSAudit(args []string) error {
 		r = f
 	}
 
+	// Each line is one envelope, so a line may be at most --max-size bytes.
+	maxLine := math.MaxInt
+	if limits.MaxSize > 0 && limits.MaxSize < math.MaxInt-1 {
+		maxLine = int(limits.MaxSize) + 1
+	}
 	sc := bufio.NewScanner(r)
-	buf := make([]byte, 0, 1024*1024)
-	sc.Buffer(buf, 10*1024*1024)
+	buf := make([]byte, 0, min(1024*1024, maxLine))
+	sc.Buffer(buf, maxLine)
 
 	var envs []core.Envelope
 	for sc.Scan() {
@@ -76,12 +86,26 @@ func runTSAudit(args []string) error {
 			continue
 		}
 		var e core.Envelope
-		if err := json.Unmarshal(line, &e); err != nil {
-			return err
+		if *lenient {
+			if err := canonical.CheckLimits(line, limits); err != nil {
+				return fmt.Errorf("index %d: %w", len(envs), err)
+			}
+			if err := json.Unmarshal(line, &e); err != nil {
+				return err
+			}
+		} else {
+			var err error
+			e, err = core.ParseEnvelopeStrictWithLimits(line, limits)
+			if err != nil {
+				return fmt.Errorf("index %d: %w", len(envs), err)
+			}
 		}
 		envs = append(envs, e)
 	}
 	if err := sc.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return fmt.Errorf("index %d: %w", len(envs), &canonical.LimitError{Err: canonical.ErrTooLarge, Limit: limits.MaxSize, Offset: limits.MaxSize})
+		}
 		return err
 	}
 
@@ -20,7 +20,7 @@ func runTSInit(args []string) error {
 	fs.SetOutput(io.Discard)
 
 	kid := fs.String("kid", "", "key id")
-	payloadEncoding := fs.String("payload-encoding", core.V1PayloadEncodingJCS, "payload encoding: jcs or raw")
+	payloadEncoding := fs.String("payload-encoding", core.V1PayloadEncodingJCS, "payload encoding: jcs, raw, text, cbor, yaml, ndjson, sd-jcs, tar or zip")
 	outPath := fs.String("output", "", "output file path (default: stdout)")
 	jsonOut := fs.Bool("json", false, "output result as JSON (for CI / automation); when set, writes envelope to --output")
 
@@ -1,24 +1,49 @@
 package main
 
 import (
+	"bytes"
+	"crypto/ed25519"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
+	"github.com/na0h/veriseal/canonical"
 	"github.com/na0h/veriseal/core"
 	"github.com/na0h/veriseal/crypto"
 )
 
 type verifyResult struct {
-	OK             bool   `json:"ok"`
-	SignatureOK    bool   `json:"signature_ok"`
-	PayloadHashOK  *bool  `json:"payload_hash_ok,omitempty"`
-	Error          string `json:"error,omitempty"`
-	SignatureError string `json:"signature_error,omitempty"`
-	PayloadError   string `json:"payload_error,omitempty"`
+	OK             bool                   `json:"ok"`
+	SignatureOK    bool                   `json:"signature_ok"`
+	PayloadHashOK  *bool                  `json:"payload_hash_ok,omitempty"`
+	Error          string                 `json:"error,omitempty"`
+	SignatureError string                 `json:"signature_error,omitempty"`
+	PayloadError   string                 `json:"payload_error,omitempty"`
+	CountersignOK  *bool                  `json:"countersignature_ok,omitempty"`
+	CountersignErr string                 `json:"countersignature_error,omitempty"`
+	MigrationOK    *bool                  `json:"migration_link_ok,omitempty"`
+	MigrationErr   string                 `json:"migration_link_error,omitempty"`
+	Threshold      int                    `json:"threshold,omitempty"`
+	Signatures     []core.SignatureStatus `json:"signatures,omitempty"`
+	PayloadInclude []string               `json:"payload_include,omitempty"`
+	PayloadExclude []string               `json:"payload_exclude,omitempty"`
+	PayloadRecords []core.RecordMismatch  `json:"payload_records,omitempty"`
+	PayloadEntries []core.EntryMismatch   `json:"payload_entries,omitempty"`
+	PayloadExplain string                 `json:"payload_explain,omitempty"`
+	Disclosed      json.RawMessage        `json:"disclosed,omitempty"`
+	Redacted       int                    `json:"redacted,omitempty"`
+}
+
+// payloadOptions selects the optional reports of checkPayload and caps the
+// payload fetched by payload_ref.
+type payloadOptions struct {
+	explain     bool  // trace an ndjson / tar / zip mismatch to records or entries
+	disclosed   bool  // rebuild the disclosed fields of an sd-jcs payload
+	maxResolved int64 // maximum payload_ref size in bytes (0: no limit)
 }
 
 func runVerify(args []string) error {
@@ -28,9 +53,26 @@ func runVerify(args []string) error {
 	pubPath := fs.String("pubkey", "", "path to ed25519 public key")
 	inPath := fs.String("input", "", "input signed envelope JSON file path")
 	payloadFile := fs.String("payload-file", "", "payload file path (optional)")
+	trustStore := fs.String("trust-store", "", "trust store JSON mapping kid to public key PEM path")
+	counterPath := fs.String("countersignature", "", "countersignature JSON file")
+	witnessPath := fs.String("countersigned-by", "", "path to the witness ed25519 public key")
+	migratedFrom := fs.String("migrated-from", "", "original v1 envelope JSON file; checks the migrated_from link")
+	migratedFromPub := fs.String("migrated-from-pubkey", "", "path to the ed25519 public key of the original v1 envelope (default: --pubkey)")
+	threshold := fs.Int("threshold", 0, "number of distinct trusted signatures required (requires --trust-store)")
+	format := fs.String("format", formatJSON, "input format: json, cose or dsse")
+	resolve := fs.Bool("resolve", false, "fetch the payload by payload_ref (file:// with --payload-root, sha256:<hex> with --cas-dir) once the signature verifies")
+	casDir := fs.String("cas-dir", "", "content-addressed blob directory (<dir>/sha256/<hex>) for --resolve")
+	payloadRoot := fs.String("payload-root", "", "directory file:// payload_refs must lie in, for --resolve")
+	maxResolved := fs.Int64("max-payload-size", core.DefaultMaxResolvedSize, "maximum size in bytes of a payload fetched by --resolve (0: no limit)")
+	allDir := fs.String("all", "", "verify every sidecar (*"+sidecarExt+") under this directory")
+	explain := fs.Bool("explain", false, "on a payload hash mismatch, report which ndjson records or tar / zip entries differ (needs payload_record_hashes / payload_entry_hashes)")
+	disclosed := fs.Bool("disclosed", false, "sd-jcs: print the disclosed fields (and count the redacted ones) once the payload verifies")
+	lenient := fs.Bool("lenient", false, "accept unknown, duplicate and case-variant fields (legacy parsing)")
+	applyLimits := limitFlags(fs)
 	jsonOut := fs.Bool("json", false, "output result as JSON (for CI / automation)")
 
-	if err := parseFlags(fs, args); err != nil {
+	positional, err := parseFlagsAndArgs(fs, args)
+	if err != nil {
 		if errors.Is(err, flag.ErrHelp) {
 			printVerifyUsage(os.Stdout)
 			return nil
@@ -38,72 +80,455 @@ func runVerify(args []string) error {
 		printVerifyUsage(os.Stderr)
 		return err
 	}
+	if len(positional) > 1 {
+		printVerifyUsage(os.Stderr)
+		return fmt.Errorf("too many arguments: %v", positional)
+	}
+	sidecarMode := len(positional) == 1 || *allDir != ""
+	limits := applyLimits()
+	popts := payloadOptions{explain: *explain, disclosed: *disclosed, maxResolved: *maxResolved}
 
-	if *pubPath == "" {
+	if *pubPath == "" && *trustStore == "" {
 		printVerifyUsage(os.Stderr)
 		return fmt.Errorf("missing --pubkey")
 	}
-	if *inPath == "" {
+	if sidecarMode {
+		if len(positional) == 1 && *allDir != "" {
+			printVerifyUsage(os.Stderr)
+			return fmt.Errorf("a payload file cannot be used with --all")
+		}
+		if *allDir != "" && (*explain || *disclosed) {
+			printVerifyUsage(os.Stderr)
+			return fmt.Errorf("--explain and --disclosed cannot be used with --all")
+		}
+		if *inPath != "" || *payloadFile != "" || *resolve || *format != formatJSON || *counterPath != "" || *migratedFrom != "" {
+			printVerifyUsage(os.Stderr)
+			return fmt.Errorf("sidecar verification cannot be used with --input, --payload-file, --resolve, --format, --countersignature or --migrated-from")
+		}
+	} else if *inPath == "" {
 		printVerifyUsage(os.Stderr)
 		return fmt.Errorf("missing --input")
 	}
+	if *threshold != 0 && *trustStore == "" {
+		printVerifyUsage(os.Stderr)
+		return fmt.Errorf("missing --trust-store (required when --threshold is set)")
+	}
+	if *migratedFromPub != "" && *migratedFrom == "" {
+		printVerifyUsage(os.Stderr)
+		return fmt.Errorf("missing --migrated-from (required when --migrated-from-pubkey is set)")
+	}
+	if *migratedFrom != "" && *migratedFromPub == "" {
+		if *pubPath == "" {
+			printVerifyUsage(os.Stderr)
+			return fmt.Errorf("missing --migrated-from-pubkey (required with --trust-store)")
+		}
+		*migratedFromPub = *pubPath
+	}
+	if (*counterPath == "") != (*witnessPath == "") {
+		printVerifyUsage(os.Stderr)
+		return fmt.Errorf("--countersignature and --countersigned-by must be set together")
+	}
+	switch *format {
+	case formatJSON:
+	case formatCOSE:
+		if *trustStore != "" || *counterPath != "" || *migratedFrom != "" || *lenient {
+			printVerifyUsage(os.Stderr)
+			return fmt.Errorf("--format cose supports --pubkey and --payload-file only")
+		}
+	case formatDSSE:
+		if *counterPath != "" || *migratedFrom != "" || *lenient || *explain || *disclosed {
+			printVerifyUsage(os.Stderr)
+			return fmt.Errorf("--format dsse supports --pubkey, --trust-store, --threshold and --payload-file only")
+		}
+	default:
+		printVerifyUsage(os.Stderr)
+		return fmt.Errorf("unsupported --format: %s", *format)
+	}
+	if *resolve && *payloadFile != "" {
+		printVerifyUsage(os.Stderr)
+		return fmt.Errorf("--resolve cannot be used with --payload-file")
+	}
+	if (*casDir != "" || *payloadRoot != "") && !*resolve {
+		printVerifyUsage(os.Stderr)
+		return fmt.Errorf("--cas-dir and --payload-root require --resolve")
+	}
+	if *threshold < 0 {
+		return fmt.Errorf("invalid --threshold: %d", *threshold)
+	}
 
-	pub, err := crypto.LoadEd25519PublicKey(*pubPath)
-	if err != nil {
-		return err
+	var pub ed25519.PublicKey
+	var keys map[string]ed25519.PublicKey
+	if *trustStore != "" {
+		keys, err = crypto.LoadEd25519TrustStore(*trustStore)
+		if err != nil {
+			return err
+		}
+		if *threshold == 0 {
+			*threshold = 1
+		}
+	} else {
+		pub, err = crypto.LoadEd25519PublicKey(*pubPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case *allDir != "":
+		return verifyAllSidecars(*allDir, pub, keys, *threshold, limits, *lenient, *jsonOut)
+	case sidecarMode:
+		input, err := os.ReadFile(sidecarPath(positional[0]))
+		if err != nil {
+			return err
+		}
+		_, res, err := checkEnvelope(input, positional[0], nil, pub, keys, *threshold, limits, *lenient, popts)
+		if err != nil {
+			return err
+		}
+		res.finish()
+		return printVerifyResult(res, *jsonOut)
 	}
 
-	input, err := readInput(*inPath)
+	input, err := readInputLimited(*inPath, limits.MaxSize)
 	if err != nil {
 		return err
 	}
 
+	if *format == formatDSSE {
+		return verifyDSSE(input, *payloadFile, pub, keys, *threshold, limits, *jsonOut)
+	}
+
+	var resolver core.PayloadResolver
+	if *resolve {
+		// without --payload-root, file:// refs fail with "no root directory set"
+		schemes := core.SchemeResolver{"file": core.FileResolver{Root: *payloadRoot}}
+		if *casDir != "" {
+			schemes[core.V1PayloadHashAlgSHA256] = core.CASResolver{Dir: *casDir}
+		}
+		resolver = schemes
+	}
+
 	var envelope core.Envelope
-	if err := json.Unmarshal(input, &envelope); err != nil {
+	var res verifyResult
+	if *format == formatCOSE {
+		envelope, res, err = checkCOSE(input, *payloadFile, resolver, pub, popts)
+	} else {
+		envelope, res, err = checkEnvelope(input, *payloadFile, resolver, pub, keys, *threshold, limits, *lenient, popts)
+	}
+	if err != nil {
 		return err
 	}
 
-	res := verifyResult{}
+	// Optional countersignature verification
+	if *witnessPath != "" {
+		witnessPub, err := crypto.LoadEd25519PublicKey(*witnessPath)
+		if err != nil {
+			return err
+		}
+		counterBytes, err := readInput(*counterPath)
+		if err != nil {
+			return err
+		}
+		if err := core.VerifyCountersignatureEd25519(input, counterBytes, witnessPub); err != nil {
+			f := false
+			res.CountersignOK = &f
+			res.CountersignErr = err.Error()
+		} else {
+			t := true
+			res.CountersignOK = &t
+		}
+	}
 
-	// Optional payload hash verification
-	if *payloadFile != "" {
-		payloadBytes, err := os.ReadFile(*payloadFile)
+	// Optional migration link verification
+	if *migratedFrom != "" {
+		originalPub, err := crypto.LoadEd25519PublicKey(*migratedFromPub)
 		if err != nil {
 			return err
 		}
-		if err := core.VerifyPayloadHash(envelope, payloadBytes); err != nil {
+		originalBytes, err := readInput(*migratedFrom)
+		if err != nil {
+			return err
+		}
+		if err := core.VerifyMigrationLink(envelope, originalBytes, originalPub); err != nil {
 			f := false
-			res.PayloadHashOK = &f
-			res.PayloadError = err.Error()
+			res.MigrationOK = &f
+			res.MigrationErr = err.Error()
 		} else {
 			t := true
-			res.PayloadHashOK = &t
+			res.MigrationOK = &t
+		}
+	}
+
+	res.finish()
+	return printVerifyResult(res, *jsonOut)
+}
+
+// finish sets the overall result and picks a primary error message for
+// automation.
+func (res *verifyResult) finish() {
+	res.OK = res.SignatureOK &&
+		(res.PayloadHashOK == nil || *res.PayloadHashOK) &&
+		(res.CountersignOK == nil || *res.CountersignOK) &&
+		(res.MigrationOK == nil || *res.MigrationOK)
+	if res.OK {
+		return
+	}
+	if !res.SignatureOK {
+		res.Error = res.SignatureError
+	} else if res.PayloadHashOK != nil && !*res.PayloadHashOK {
+		res.Error = res.PayloadError
+	} else if res.CountersignOK != nil && !*res.CountersignOK {
+		res.Error = res.CountersignErr
+	} else if res.MigrationOK != nil && !*res.MigrationOK {
+		res.Error = res.MigrationErr
+	}
+	if res.Error == "" {
+		res.Error = "verification failed"
+	}
+}
+
+// checkEnvelope parses a JSON envelope within limits and verifies its
+// signatures and, when a payload is available, its payload_hash.
+func checkEnvelope(input []byte, payloadFile string, resolver core.PayloadResolver, pub ed25519.PublicKey, keys map[string]ed25519.PublicKey, threshold int, limits canonical.Limits, lenient bool, popts payloadOptions) (core.Envelope, verifyResult, error) {
+	var envelope core.Envelope
+	var err error
+	if lenient {
+		if err := canonical.CheckLimits(input, limits); err != nil {
+			return core.Envelope{}, verifyResult{}, err
+		}
+		if err := json.Unmarshal(input, &envelope); err != nil {
+			return core.Envelope{}, verifyResult{}, err
+		}
+	} else {
+		envelope, err = core.ParseEnvelopeStrictWithLimits(input, limits)
+		if err != nil {
+			return core.Envelope{}, verifyResult{}, err
 		}
 	}
 
-	// Signature verification
-	if err := core.VerifyEd25519(envelope, pub); err != nil {
-		res.SignatureOK = false
+	res := verifyResult{PayloadInclude: envelope.PayloadInclude, PayloadExclude: envelope.PayloadExclude}
+	switch {
+	case keys != nil:
+		res.Threshold = threshold
+		if lenient {
+			res.Signatures, err = core.VerifyThresholdEd25519(envelope, keys, threshold)
+		} else {
+			res.Signatures, err = core.VerifyThresholdEd25519JSONWithLimits(input, keys, threshold, limits)
+		}
+	case lenient:
+		err = core.VerifyEd25519(envelope, pub)
+	default:
+		_, err = core.VerifyEd25519JSONWithLimits(input, pub, limits)
+	}
+	if err != nil {
+		res.SignatureError = err.Error()
+	} else {
+		res.SignatureOK = true
+	}
+
+	if err := checkPayload(&res, envelope, payloadFile, resolver, popts); err != nil {
+		return core.Envelope{}, verifyResult{}, err
+	}
+	return envelope, res, nil
+}
+
+// checkCOSE is checkEnvelope for a COSE_Sign1 message.
+func checkCOSE(input []byte, payloadFile string, resolver core.PayloadResolver, pub ed25519.PublicKey, popts payloadOptions) (core.Envelope, verifyResult, error) {
+	envelope, err := core.ImportCOSE(input, nil)
+	if err != nil {
+		return core.Envelope{}, verifyResult{}, err
+	}
+
+	res := verifyResult{PayloadInclude: envelope.PayloadInclude, PayloadExclude: envelope.PayloadExclude}
+	if _, err := core.VerifyCOSE(input, pub); err != nil {
 		res.SignatureError = err.Error()
 	} else {
 		res.SignatureOK = true
 	}
 
-	// Overall result
-	res.OK = res.SignatureOK && (res.PayloadHashOK == nil || *res.PayloadHashOK)
-	if !res.OK {
-		// Choose a primary error message for automation.
-		if !res.SignatureOK {
-			res.Error = res.SignatureError
-		} else if res.PayloadHashOK != nil && !*res.PayloadHashOK {
-			res.Error = res.PayloadError
+	if err := checkPayload(&res, envelope, payloadFile, resolver, popts); err != nil {
+		return core.Envelope{}, verifyResult{}, err
+	}
+	return envelope, res, nil
+}
+
+// checkPayload verifies payload_hash against the payload file, the payload
+// fetched by payload_ref (when a resolver is given and res says the
+// signature verified: payload_ref is chosen by the envelope's author, so an
+// unverified envelope must not make us read anything), or else the attached
+// payload; without any of them the result stays unknown. popts adds the
+// optional reports: which ndjson records or archive entries differ on a
+// mismatch, and which sd-jcs fields are disclosed once the payload
+// verifies.
+func checkPayload(res *verifyResult, envelope core.Envelope, payloadFile string, resolver core.PayloadResolver, popts payloadOptions) error {
+	if popts.disclosed && envelope.PayloadEncoding != core.V1PayloadEncodingSD {
+		return fmt.Errorf("--disclosed requires payload_encoding=%s (got %s)", core.V1PayloadEncodingSD, envelope.PayloadEncoding)
+	}
+
+	var payloadBytes []byte
+	var err error
+	switch {
+	case payloadFile != "" && !popts.explain && !popts.disclosed:
+		// Stream the file through the hash, so large payloads never have to
+		// fit in memory; the reports above need the payload bytes.
+		f, err := os.Open(payloadFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close() //nolint:errcheck
+		ok := true
+		if err := verifyPayloadReader(envelope, f); err != nil {
+			ok = false
+			res.PayloadError = err.Error()
+		}
+		res.PayloadHashOK = &ok
+		return nil
+	case payloadFile != "":
+		payloadBytes, err = os.ReadFile(payloadFile)
+		if err != nil {
+			return err
+		}
+	case resolver != nil && !res.SignatureOK:
+		res.PayloadError = "payload_ref not resolved: signature not verified"
+		return nil
+	case resolver != nil:
+		payloadBytes, err = core.ResolvePayloadWithLimit(envelope, resolver, popts.maxResolved)
+		if err != nil {
+			f := false
+			res.PayloadHashOK = &f
+			res.PayloadError = err.Error()
+			return nil
+		}
+	case len(envelope.Payload) > 0:
+		payloadBytes, err = core.AttachedPayload(envelope)
+		if err != nil {
+			f := false
+			res.PayloadHashOK = &f
+			res.PayloadError = err.Error()
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	if err := core.VerifyPayloadHash(envelope, payloadBytes); err != nil {
+		f := false
+		res.PayloadHashOK = &f
+		res.PayloadError = err.Error()
+		switch {
+		case !popts.explain:
+		case envelope.PayloadEncoding == core.V1PayloadEncodingTar || envelope.PayloadEncoding == core.V1PayloadEncodingZip:
+			res.PayloadEntries, err = core.ExplainArchiveMismatch(envelope, payloadBytes)
+			if err != nil {
+				res.PayloadExplain = err.Error()
+			} else if len(res.PayloadEntries) == 0 {
+				res.PayloadExplain = "every entry matches payload_entry_hashes"
+			}
+		default:
+			res.PayloadRecords, err = core.ExplainNDJSONMismatch(envelope, payloadBytes)
+			if err != nil {
+				res.PayloadExplain = err.Error()
+			} else if len(res.PayloadRecords) == 0 {
+				res.PayloadExplain = "every record matches payload_record_hashes"
+			}
+		}
+	} else {
+		t := true
+		res.PayloadHashOK = &t
+		if popts.disclosed {
+			disclosed, redacted, err := core.DisclosedPayload(payloadBytes)
+			if err != nil {
+				return err
+			}
+			res.Disclosed = disclosed
+			res.Redacted = redacted
+		}
+	}
+	return nil
+}
+
+// verifyPayloadFile checks a payload file against payload_hash.
+func verifyPayloadFile(envelope core.Envelope, payloadFile string) error {
+	f, err := os.Open(payloadFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close() //nolint:errcheck
+	return verifyPayloadReader(envelope, f)
+}
+
+// verifyPayloadReader checks a payload against payload_hash as it is read,
+// in constant memory for raw and jcs payloads (see core.NewVerifyingReader).
+func verifyPayloadReader(envelope core.Envelope, r io.Reader) error {
+	vr, err := core.NewVerifyingReader(envelope, r)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(io.Discard, vr)
+	return err
+}
+
+// verifyDSSE checks the DSSE signatures and, with a payload file, that the
+// file matches the DSSE payload after normalization.
+func verifyDSSE(input []byte, payloadFile string, pub ed25519.PublicKey, keys map[string]ed25519.PublicKey, threshold int, limits canonical.Limits, jsonOut bool) error {
+	d, err := core.ParseDSSEWithLimits(input, limits)
+	if err != nil {
+		return err
+	}
+
+	res := verifyResult{}
+
+	if payloadFile != "" {
+		payloadBytes, err := os.ReadFile(payloadFile)
+		if err != nil {
+			return err
 		}
-		if res.Error == "" {
-			res.Error = "verification failed"
+		err = verifyDSSEPayload(d, payloadBytes)
+		ok := err == nil
+		res.PayloadHashOK = &ok
+		if err != nil {
+			res.PayloadError = err.Error()
 		}
 	}
 
-	if *jsonOut {
+	if keys != nil {
+		res.Threshold = threshold
+		res.Signatures, err = core.VerifyDSSEThresholdEd25519(d, keys, threshold)
+	} else {
+		err = core.VerifyDSSEEd25519(d, pub)
+	}
+	if err != nil {
+		res.SignatureError = err.Error()
+	} else {
+		res.SignatureOK = true
+	}
+
+	res.finish()
+	return printVerifyResult(res, jsonOut)
+}
+
+func verifyDSSEPayload(d core.DSSE, payloadBytes []byte) error {
+	carried, err := d.DecodePayload()
+	if err != nil {
+		return err
+	}
+	enc := core.DSSEPayloadEncoding(d.PayloadType)
+	want, err := core.ComputePayloadHash(carried, enc)
+	if err != nil {
+		return err
+	}
+	got, err := core.ComputePayloadHash(payloadBytes, enc)
+	if err != nil {
+		return err
+	}
+	if got != want {
+		return fmt.Errorf("payload hash mismatch")
+	}
+	return nil
+}
+
+func printVerifyResult(res verifyResult, jsonOut bool) error {
+	if jsonOut {
 		enc := json.NewEncoder(os.Stdout)
 		enc.SetEscapeHTML(false)
 		if err := enc.Encode(res); err != nil {
@@ -124,10 +549,20 @@ func runVerify(args []string) error {
 			fmt.Fprintln(os.Stdout, "  reason:", res.SignatureError)
 		}
 	}
+	for _, st := range res.Signatures {
+		if st.OK {
+			fmt.Fprintf(os.Stdout, "  signature %s: OK\n", st.Kid)
+		} else {
+			fmt.Fprintf(os.Stdout, "  signature %s: FAILED (%s)\n", st.Kid, st.Error)
+		}
+	}
 
 	switch {
 	case res.PayloadHashOK == nil:
 		fmt.Fprintln(os.Stdout, "Verify payload hash: UNKNOWN")
+		if res.PayloadError != "" {
+			fmt.Fprintln(os.Stdout, "  reason:", res.PayloadError)
+		}
 	case *res.PayloadHashOK:
 		fmt.Fprintln(os.Stdout, "Verify payload hash: OK")
 	default:
@@ -135,6 +570,55 @@ func runVerify(args []string) error {
 		if res.PayloadError != "" {
 			fmt.Fprintln(os.Stdout, "  reason:", res.PayloadError)
 		}
+		for _, r := range res.PayloadRecords {
+			if r.Line > 0 {
+				fmt.Fprintf(os.Stdout, "  record %d (line %d): %s\n", r.Record, r.Line, r.Reason)
+			} else {
+				fmt.Fprintf(os.Stdout, "  record %d: %s\n", r.Record, r.Reason)
+			}
+		}
+		for _, e := range res.PayloadEntries {
+			fmt.Fprintf(os.Stdout, "  entry %s: %s\n", e.Path, e.Reason)
+		}
+		if res.PayloadExplain != "" {
+			fmt.Fprintln(os.Stdout, "  explain:", res.PayloadExplain)
+		}
+	}
+	// The hash covers only part of the payload; say which part.
+	if len(res.PayloadInclude) > 0 {
+		fmt.Fprintln(os.Stdout, "  covers only:", strings.Join(res.PayloadInclude, ", "))
+	}
+	if len(res.PayloadExclude) > 0 {
+		fmt.Fprintln(os.Stdout, "  not covered:", strings.Join(res.PayloadExclude, ", "))
+	}
+	if res.Disclosed != nil {
+		if res.Redacted > 0 {
+			fmt.Fprintf(os.Stdout, "  redacted: %d field(s)\n", res.Redacted)
+		}
+		var b bytes.Buffer
+		if err := json.Indent(&b, res.Disclosed, "", "  "); err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stdout, "Disclosed payload:")
+		fmt.Fprintln(os.Stdout, b.String())
+	}
+
+	if res.CountersignOK != nil {
+		if *res.CountersignOK {
+			fmt.Fprintln(os.Stdout, "Verify countersignature: OK")
+		} else {
+			fmt.Fprintln(os.Stdout, "Verify countersignature: FAILED")
+			fmt.Fprintln(os.Stdout, "  reason:", res.CountersignErr)
+		}
+	}
+
+	if res.MigrationOK != nil {
+		if *res.MigrationOK {
+			fmt.Fprintln(os.Stdout, "Verify migrated_from: OK")
+		} else {
+			fmt.Fprintln(os.Stdout, "Verify migrated_from: FAILED")
+			fmt.Fprintln(os.Stdout, "  reason:", res.MigrationErr)
+		}
 	}
 
 	if !res.OK {
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/na0h/veriseal/core"
+	"github.com/na0h/veriseal/crypto"
+)
+
+type subjectStatus struct {
+	Name  string `json:"name"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+type verifyAttestationResult struct {
+	OK             bool            `json:"ok"`
+	SignatureOK    bool            `json:"signature_ok"`
+	PredicateType  string          `json:"predicate_type,omitempty"`
+	Error          string          `json:"error,omitempty"`
+	SignatureError string          `json:"signature_error,omitempty"`
+	Subjects       []subjectStatus `json:"subjects,omitempty"`
+}
+
+func runVerifyAttestation(args []string) error {
+	fs := flag.NewFlagSet("verify-attestation", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var subjects stringList
+	pubPath := fs.String("pubkey", "", "path to ed25519 public key")
+	inPath := fs.String("input", "", "attestation envelope JSON file")
+	fs.Var(&subjects, "subject", "local file to check against the subject of the same name (repeatable)")
+	baseDir := fs.String("base-dir", ".", "directory subject names are resolved against when --subject is not set")
+	predicateType := fs.String("predicate-type", "", "required predicateType (optional)")
+	jsonOut := fs.Bool("json", false, "output result as JSON (for CI / automation)")
+
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printVerifyAttestationUsage(os.Stdout)
+			return nil
+		}
+		printVerifyAttestationUsage(os.Stderr)
+		return err
+	}
+
+	if *pubPath == "" {
+		printVerifyAttestationUsage(os.Stderr)
+		return fmt.Errorf("missing --pubkey")
+	}
+	if *inPath == "" {
+		printVerifyAttestationUsage(os.Stderr)
+		return fmt.Errorf("missing --input")
+	}
+
+	pub, err := crypto.LoadEd25519PublicKey(*pubPath)
+	if err != nil {
+		return err
+	}
+	input, err := readInput(*inPath)
+	if err != nil {
+		return err
+	}
+
+	res := verifyAttestationResult{}
+
+	envelope, err := core.VerifyEd25519JSON(input, pub)
+	if err != nil {
+		res.SignatureError = err.Error()
+	} else {
+		res.SignatureOK = true
+	}
+
+	var st core.Statement
+	if res.SignatureOK {
+		st, err = core.AttestationStatement(envelope)
+		if err != nil {
+			res.Error = err.Error()
+		} else {
+			res.PredicateType = st.PredicateType
+			if *predicateType != "" && st.PredicateType != *predicateType {
+				res.Error = fmt.Sprintf("predicateType mismatch: want %s, got %s", *predicateType, st.PredicateType)
+			}
+		}
+	}
+
+	if res.SignatureOK && res.Error == "" {
+		res.Subjects = checkSubjects(st, subjects, *baseDir)
+		for _, s := range res.Subjects {
+			if !s.OK {
+				res.Error = s.Error
+				break
+			}
+		}
+	}
+
+	res.OK = res.SignatureOK && res.Error == ""
+	if !res.SignatureOK {
+		res.Error = res.SignatureError
+	}
+
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(res); err != nil {
+			return err
+		}
+		if !res.OK {
+			return errors.New(res.Error)
+		}
+		return nil
+	}
+
+	// Human-readable output
+	if res.SignatureOK {
+		fmt.Fprintln(os.Stdout, "Verify signed: OK")
+	} else {
+		fmt.Fprintln(os.Stdout, "Verify signed: FAILED")
+		fmt.Fprintln(os.Stdout, "  reason:", res.SignatureError)
+	}
+	if res.PredicateType != "" {
+		fmt.Fprintln(os.Stdout, "Predicate type:", res.PredicateType)
+	}
+	for _, s := range res.Subjects {
+		if s.OK {
+			fmt.Fprintf(os.Stdout, "  subject %s: OK\n", s.Name)
+		} else {
+			fmt.Fprintf(os.Stdout, "  subject %s: FAILED (%s)\n", s.Name, s.Error)
+		}
+	}
+
+	if !res.OK {
+		return errors.New(res.Error)
+	}
+	return nil
+}
+
+// checkSubjects verifies the given files against the subjects of the same
+// name, or every subject (resolved against baseDir) when no file is given.
+func checkSubjects(st core.Statement, files []string, baseDir string) []subjectStatus {
+	byName := map[string]core.Subject{}
+	for _, s := range st.Subject {
+		byName[s.Name] = s
+	}
+
+	var statuses []subjectStatus
+	check := func(name, path string) {
+		ss := subjectStatus{Name: name}
+		s, ok := byName[name]
+		if !ok {
+			ss.Error = fmt.Sprintf("subject %s: not in attestation", name)
+			statuses = append(statuses, ss)
+			return
+		}
+		b, err := os.ReadFile(path)
+		if err == nil {
+			err = core.VerifySubject(s, b)
+		}
+		if err != nil {
+			ss.Error = err.Error()
+		} else {
+			ss.OK = true
+		}
+		statuses = append(statuses, ss)
+	}
+
+	if len(files) > 0 {
+		for _, f := range files {
+			check(filepath.ToSlash(f), f)
+		}
+		return statuses
+	}
+	for _, s := range st.Subject {
+		// Subject names come from the statement being verified: never let
+		// them point outside --base-dir.
+		path := filepath.FromSlash(s.Name)
+		if !filepath.IsLocal(path) {
+			statuses = append(statuses, subjectStatus{Name: s.Name, Error: fmt.Sprintf("subject %s: not a relative path inside --base-dir", s.Name)})
+			continue
+		}
+		check(s.Name, filepath.Join(baseDir, path))
+	}
+	return statuses
+}
@@ -4,6 +4,10 @@ import (
 	"flag"
 	"io"
 	"os"
+	"strings"
+
+	"github.com/na0h/veriseal/canonical"
+	"github.com/na0h/veriseal/core"
 )
 
 func parseFlags(fs *flag.FlagSet, args []string) error {
@@ -14,6 +18,22 @@ func parseFlags(fs *flag.FlagSet, args []string) error {
 	return nil
 }
 
+// parseFlagsAndArgs is parseFlags for commands that take positional
+// arguments; flags may appear before or after them.
+func parseFlagsAndArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
 func readInput(path string) ([]byte, error) {
 	if path == "" {
 		return io.ReadAll(os.Stdin)
@@ -21,6 +41,44 @@ func readInput(path string) ([]byte, error) {
 	return os.ReadFile(path)
 }
 
+// readInputLimited is readInput that stops reading after max bytes (no limit
+// when max is 0), so oversized untrusted input is never loaded completely.
+func readInputLimited(path string, max int64) ([]byte, error) {
+	if max <= 0 {
+		return readInput(path)
+	}
+	var r io.Reader = os.Stdin
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close() //nolint:errcheck
+		r = f
+	}
+	b, err := io.ReadAll(io.LimitReader(r, max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(b)) > max {
+		return nil, &canonical.LimitError{Err: canonical.ErrTooLarge, Limit: max, Offset: max}
+	}
+	return b, nil
+}
+
+// limitFlags registers --max-depth, --max-size, --max-keys and
+// --max-string-length, defaulting to core.DefaultEnvelopeLimits. The
+// returned function reports the parsed values, to be passed to the
+// WithLimits parsers.
+func limitFlags(fs *flag.FlagSet) func() canonical.Limits {
+	l := core.DefaultEnvelopeLimits
+	fs.IntVar(&l.MaxDepth, "max-depth", l.MaxDepth, "maximum JSON nesting depth of envelopes (0: no limit)")
+	fs.Int64Var(&l.MaxSize, "max-size", l.MaxSize, "maximum envelope size in bytes (0: no limit)")
+	fs.IntVar(&l.MaxKeys, "max-keys", l.MaxKeys, "maximum members per JSON object in envelopes (0: no limit)")
+	fs.IntVar(&l.MaxStringLength, "max-string-length", l.MaxStringLength, "maximum JSON string length in envelopes, in bytes (0: no limit)")
+	return func() canonical.Limits { return l }
+}
+
 func writeOutput(path string, b []byte) error {
 	if path == "" {
 		_, err := os.Stdout.Write(b)
@@ -28,3 +86,13 @@ func writeOutput(path string, b []byte) error {
 	}
 	return os.WriteFile(path, b, 0644)
 }
+
+// stringList is a repeatable string flag.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
@@ -21,11 +21,21 @@ type command struct {
 
 func main() {
 	cmds := []command{
-		{name: "canon", run: runCanon, help: "Canonicalize JSON input using JCS."},
-		{name: "init", run: runInit, help: "Print an Envelope v1 JSON template."},
+		{name: "canon", run: runCanon, help: "Canonicalize JSON (JCS), CBOR or YAML input, or diff two JSON files."},
+		{name: "init", run: runInit, help: "Print an Envelope JSON template (v1 by default)."},
 		{name: "ts", run: runTS, help: "Timeseries helpers (init/next/check/audit)."},
 		{name: "sign", run: runSign, help: "Sign an envelope template with Ed25519 using a payload file."},
+		{name: "countersign", run: runCountersign, help: "Countersign a signed envelope as a witness (sets iat)."},
 		{name: "verify", run: runVerify, help: "Verify Ed25519 signature and optionally verify payload_hash using a payload file."},
+		{name: "migrate", run: runMigrate, help: "Migrate a signed v1 envelope to v2 (re-sign or wrap)."},
+		{name: "attest", run: runAttest, help: "Sign an in-toto Statement (e.g. SLSA provenance) over artifact files."},
+		{name: "verify-attestation", run: runVerifyAttestation, help: "Verify an attestation and check subject digests against local files."},
+		{name: "sign-dir", run: runSignDir, help: "Sign a manifest of every file in a directory tree."},
+		{name: "verify-dir", run: runVerifyDir, help: "Verify a directory tree against a signed manifest."},
+		{name: "export", run: runExport, help: "Convert a signed envelope to another format (jws, cose, dsse)."},
+		{name: "import", run: runImport, help: "Convert a signed envelope from another format (jws, cose, dsse)."},
+		{name: "extract", run: runExtract, help: "Write the attached payload of an envelope back out."},
+		{name: "redact", run: runRedact, help: "Hide fields of an sd-jcs envelope without invalidating its signature."},
 		{name: "version", run: runVersion, help: "Print veriseal version."},
 	}
 
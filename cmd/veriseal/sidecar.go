@@ -0,0 +1,142 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/na0h/veriseal/canonical"
+	"github.com/na0h/veriseal/core"
+)
+
+// A sidecar is the signed envelope of a payload file stored next to it as
+// <payload>.vseal.
+const sidecarExt = ".vseal"
+
+func sidecarPath(payloadPath string) string {
+	return payloadPath + sidecarExt
+}
+
+// sidecarTemplate builds the envelope template used by 'sign <file>' when no
+// --input is given; the payload encoding defaults to jcs for .json files,
+// cbor for .cbor files, yaml for .yaml/.yml files, ndjson for
+// .ndjson/.jsonl files, tar for .tar/.tgz/.tar.gz files and zip for .zip
+// files.
+func sidecarTemplate(payloadPath, kid, encoding string) ([]byte, error) {
+	if kid == "" {
+		return nil, fmt.Errorf("missing --kid (or --input)")
+	}
+	if encoding == "" {
+		ext := strings.ToLower(filepath.Ext(payloadPath))
+		if strings.HasSuffix(strings.ToLower(payloadPath), ".tar.gz") {
+			ext = ".tgz"
+		}
+		switch ext {
+		case ".json":
+			encoding = core.V1PayloadEncodingJCS
+		case ".cbor":
+			encoding = core.V1PayloadEncodingCBOR
+		case ".yaml", ".yml":
+			encoding = core.V1PayloadEncodingYAML
+		case ".ndjson", ".jsonl":
+			encoding = core.V1PayloadEncodingNDJSON
+		case ".tar", ".tgz":
+			encoding = core.V1PayloadEncodingTar
+		case ".zip":
+			encoding = core.V1PayloadEncodingZip
+		default:
+			encoding = core.V1PayloadEncodingRaw
+		}
+	}
+	env, err := core.NewEnvelopeTemplateV1(kid, encoding)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(env)
+}
+
+type sidecarResult struct {
+	Path string `json:"path"`
+	verifyResult
+}
+
+type verifyAllResult struct {
+	OK    bool            `json:"ok"`
+	Error string          `json:"error,omitempty"`
+	Files []sidecarResult `json:"files"`
+}
+
+// verifyAllSidecars verifies every sidecar under dir against the payload
+// file next to it.
+func verifyAllSidecars(dir string, pub ed25519.PublicKey, keys map[string]ed25519.PublicKey, threshold int, limits canonical.Limits, lenient bool, jsonOut bool) error {
+	res := verifyAllResult{OK: true, Files: []sidecarResult{}}
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, sidecarExt) {
+			return nil
+		}
+
+		payloadPath := strings.TrimSuffix(path, sidecarExt)
+		r := sidecarResult{Path: payloadPath}
+		input, err := os.ReadFile(path)
+		if err == nil {
+			_, r.verifyResult, err = checkEnvelope(input, payloadPath, nil, pub, keys, threshold, limits, lenient, payloadOptions{})
+		}
+		if err != nil {
+			r.verifyResult = verifyResult{Error: err.Error()}
+		} else {
+			r.finish()
+		}
+		if !r.OK {
+			res.OK = false
+			if res.Error == "" {
+				res.Error = fmt.Sprintf("%s: %s", payloadPath, r.Error)
+			}
+		}
+		res.Files = append(res.Files, r)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(res.Files) == 0 {
+		res.OK = false
+		res.Error = fmt.Sprintf("no sidecars (*%s) found under %s", sidecarExt, dir)
+	}
+
+	if jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(res); err != nil {
+			return err
+		}
+		if !res.OK {
+			return errors.New(res.Error)
+		}
+		return nil
+	}
+
+	failed := 0
+	for _, r := range res.Files {
+		if r.OK {
+			fmt.Fprintf(os.Stdout, "%s: OK\n", r.Path)
+		} else {
+			failed++
+			fmt.Fprintf(os.Stdout, "%s: FAILED (%s)\n", r.Path, r.Error)
+		}
+	}
+	fmt.Fprintf(os.Stdout, "Verified %d sidecars, %d failed\n", len(res.Files), failed)
+
+	if !res.OK {
+		return errors.New(res.Error)
+	}
+	return nil
+}
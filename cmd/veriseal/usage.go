@@ -11,7 +11,7 @@ func printUsage(w io.Writer, cmds []command) {
 	fmt.Fprintln(w)
 	fmt.Fprintln(w, "commands:")
 	for _, c := range cmds {
-		fmt.Fprintf(w, "  %-8s %s\n", c.name, c.help)
+		fmt.Fprintf(w, "  %-18s %s\n", c.name, c.help)
 	}
 	fmt.Fprintln(w)
 	fmt.Fprintln(w, "run 'veriseal <command> -h' for command-specific options")
@@ -29,7 +29,13 @@ func printInitUsage(w io.Writer) {
 	fmt.Fprintln(w, "  --kid               key id")
 	fmt.Fprintln(w)
 	fmt.Fprintln(w, "options:")
-	fmt.Fprintln(w, "  --payload-encoding  payload encoding: jcs or raw (default: jcs)")
+	fmt.Fprintln(w, "  --payload-encoding  payload encoding: jcs, raw, text, cbor, yaml, ndjson, sd-jcs, tar or zip (default: jcs)")
+	fmt.Fprintln(w, "  --trim-trailing-whitespace")
+	fmt.Fprintln(w, "                      text only: also trim trailing whitespace from every line")
+	fmt.Fprintln(w, "  --include <ptr>     jcs only: hash only this JSON pointer (repeatable; payload_include)")
+	fmt.Fprintln(w, "  --exclude <ptr>     jcs only: leave this JSON pointer out of the hash (repeatable;")
+	fmt.Fprintln(w, "                      payload_exclude), e.g. --exclude /updated_at")
+	fmt.Fprintln(w, "  --version           envelope version: 1 or 2 (default: 1)")
 	fmt.Fprintln(w, "  --output            output file path (default: stdout; required when --json is set)")
 	fmt.Fprintln(w, "  --json              output result as JSON (for CI / automation);")
 	fmt.Fprintln(w, "                      when set, writes generated JSON to --output (required)")
@@ -37,14 +43,31 @@ func printInitUsage(w io.Writer) {
 
 func printCanonUsage(w io.Writer) {
 	fmt.Fprintln(w, "usage: veriseal canon [options]")
+	fmt.Fprintln(w, "       veriseal canon diff <a.json> <b.json> [--json]")
 	fmt.Fprintln(w)
 	fmt.Fprintln(w, "options:")
 	fmt.Fprintln(w, "  --input   input file path (default: stdin)")
 	fmt.Fprintln(w, "  --output  output file path (default: stdout)")
+	fmt.Fprintln(w, "  --format  json (JCS, default), cbor (RFC 8949 core deterministic encoding)")
+	fmt.Fprintln(w, "            or yaml (JCS of the JSON array of documents)")
+	fmt.Fprintln(w, "  --diag    cbor only: write diagnostic notation instead of bytes")
+}
+
+func printCanonDiffUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: veriseal canon diff <a.json> <b.json> [options]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Compares two JSON files value by value and lists the differences by JSON")
+	fmt.Fprintln(w, "pointer, with the payload_hash each file gets as a jcs payload. Number")
+	fmt.Fprintln(w, "formatting, string escapes and duplicate keys are reported even when the")
+	fmt.Fprintln(w, "canonical forms match. Fails when the canonical forms differ.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "options:")
+	fmt.Fprintln(w, "  --json  output result as JSON")
 }
 
 func printSignUsage(w io.Writer) {
 	fmt.Fprintln(w, "usage: veriseal sign --privkey <path> --input <envelope.json> --payload-file <payload> [options]")
+	fmt.Fprintln(w, "       veriseal sign --privkey <path> (--kid <id> | --input <envelope.json>) <file> [options]")
 	fmt.Fprintln(w)
 	fmt.Fprintln(w, "required:")
 	fmt.Fprintln(w, "  --privkey       path to ed25519 private key (PKCS#8 PEM)")
@@ -53,23 +76,257 @@ func printSignUsage(w io.Writer) {
 	fmt.Fprintln(w)
 	fmt.Fprintln(w, "options:")
 	fmt.Fprintln(w, "  --set-iat       set iat (epoch seconds) right before signing")
+	fmt.Fprintln(w, "  --allow-non-ijson")
+	fmt.Fprintln(w, "                  sign jcs / ndjson payloads that are not I-JSON (RFC 7493)")
+	fmt.Fprintln(w, "  --record-hashes ndjson, tar and zip only: record the hash of every record")
+	fmt.Fprintln(w, "                  (payload_record_hashes) or archive entry (payload_entry_hashes)")
+	fmt.Fprintln(w, "                  so that 'verify --explain' can locate changed records / entries")
+	fmt.Fprintln(w, "  --format        json (default) or cose: write a COSE_Sign1 (CBOR) message instead")
+	fmt.Fprintln(w, "                  of a JSON envelope; cannot be combined with --attach or --append-signature")
+	fmt.Fprintln(w, "  --attach        embed the payload in the signed envelope")
+	fmt.Fprintln(w, "                  (inline JSON for jcs, base64 for other encodings)")
+	fmt.Fprintln(w, "  --payload-ref   record where the payload lives as a signed payload_ref URI")
+	fmt.Fprintln(w, "                  (e.g. file:///data/payload.bin, sha256:<hex>)")
 	fmt.Fprintln(w, "  --output        output file path (default: stdout; required when --json is set)")
 	fmt.Fprintln(w, "  --json          output result as JSON (for CI / automation);")
 	fmt.Fprintln(w, "                  when set, writes signed envelope JSON to --output (required)")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "sidecar:")
+	fmt.Fprintln(w, "  <file>              sign <file> and write the envelope to <file>.vseal (unless --output)")
+	fmt.Fprintln(w, "  --kid               key id of the template built when --input is not set")
+	fmt.Fprintln(w, "  --payload-encoding  encoding of that template (default: jcs for .json,")
+	fmt.Fprintln(w, "                      cbor for .cbor, yaml for .yaml/.yml, ndjson for .ndjson/.jsonl,")
+	fmt.Fprintln(w, "                      tar for .tar/.tgz/.tar.gz, zip for .zip, else raw)")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "multi-signature:")
+	fmt.Fprintln(w, "  --append-signature  add a signer to the signed envelope given by --input;")
+	fmt.Fprintln(w, "                      --payload-file is optional and, when set, is checked first")
+	fmt.Fprintln(w, "  --kid               key id of the appended signer (required with --append-signature)")
 }
 
 func printVerifyUsage(w io.Writer) {
 	fmt.Fprintln(w, "usage: veriseal verify --pubkey <path> --input <signed.json> [options]")
+	fmt.Fprintln(w, "       veriseal verify --trust-store <trust.json> [--threshold <n>] --input <signed.json> [options]")
+	fmt.Fprintln(w, "       veriseal verify --pubkey <path> <file>        (verifies <file>.vseal and <file>)")
+	fmt.Fprintln(w, "       veriseal verify --pubkey <path> --all <dir>   (every *.vseal sidecar under <dir>)")
 	fmt.Fprintln(w)
 	fmt.Fprintln(w, "required:")
 	fmt.Fprintln(w, "  --pubkey        path to ed25519 public key (SPKI PEM)")
 	fmt.Fprintln(w, "  --input         signed envelope JSON file")
 	fmt.Fprintln(w)
 	fmt.Fprintln(w, "options:")
-	fmt.Fprintln(w, "  --payload-file  payload file path (optional; enables payload_hash verification)")
+	fmt.Fprintln(w, "  --payload-file  payload file path (optional; enables payload_hash verification;")
+	fmt.Fprintln(w, "                  defaults to the attached payload when the envelope has one)")
+	fmt.Fprintln(w, "  --resolve       fetch the payload by payload_ref instead of --payload-file, once the")
+	fmt.Fprintln(w, "                  signature verifies (file:// URIs below --payload-root; sha256:<hex>")
+	fmt.Fprintln(w, "                  from --cas-dir)")
+	fmt.Fprintln(w, "  --payload-root  directory file:// payload_refs must lie in (symlinks may not leave it)")
+	fmt.Fprintln(w, "  --cas-dir       content-addressed blob directory laid out as <dir>/sha256/<hex>")
+	fmt.Fprintln(w, "  --max-payload-size")
+	fmt.Fprintln(w, "                  maximum size of a resolved payload in bytes (default: 268435456;")
+	fmt.Fprintln(w, "                  0: no limit)")
+	fmt.Fprintln(w, "  --trust-store   JSON object mapping kid to public key PEM path; verifies every")
+	fmt.Fprintln(w, "                  signature by kid instead of --pubkey")
+	fmt.Fprintln(w, "  --threshold     distinct trusted signatures required (default: 1; requires --trust-store)")
+	fmt.Fprintln(w, "  --countersignature  countersignature JSON file produced by 'veriseal countersign'")
+	fmt.Fprintln(w, "  --countersigned-by  path to the witness ed25519 public key; verifies --countersignature")
+	fmt.Fprintln(w, "                      over --input in addition to the original signature")
+	fmt.Fprintln(w, "  --migrated-from     original v1 envelope JSON file; checks the migrated_from link")
+	fmt.Fprintln(w, "                      and the original's signature")
+	fmt.Fprintln(w, "  --migrated-from-pubkey  public key of the original v1 signer (default: --pubkey)")
+	fmt.Fprintln(w, "  --format        json (default), cose or dsse:")
+	fmt.Fprintln(w, "                  cose: --input is a COSE_Sign1 message (only --pubkey and --payload-file apply)")
+	fmt.Fprintln(w, "                  dsse: --input is a DSSE envelope; --pubkey accepts any matching signature,")
+	fmt.Fprintln(w, "                  --trust-store / --threshold look signers up by keyid")
+	fmt.Fprintln(w, "  --explain       on a payload hash mismatch, list the ndjson records or tar / zip entries")
+	fmt.Fprintln(w, "                  that differ (needs payload_record_hashes / payload_entry_hashes,")
+	fmt.Fprintln(w, "                  see 'sign --record-hashes')")
+	fmt.Fprintln(w, "  --disclosed     sd-jcs: once the payload verifies, print the disclosed fields as JSON")
+	fmt.Fprintln(w, "                  and count the redacted ones")
+	fmt.Fprintln(w, "  --lenient       accept unknown, duplicate and case-variant fields (legacy parsing)")
+	fmt.Fprintln(w, "  --max-depth, --max-size, --max-keys, --max-string-length")
+	fmt.Fprintln(w, "                  limits on envelope JSON (default: 64, 64 MiB, 10000, 64 MiB; 0: no limit)")
 	fmt.Fprintln(w, "  --json          output result as JSON (for CI / automation)")
 }
 
+func printCountersignUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: veriseal countersign --privkey <path> --kid <id> --input <signed.json> [options]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "required:")
+	fmt.Fprintln(w, "  --privkey  path to the witness ed25519 private key (PKCS#8 PEM)")
+	fmt.Fprintln(w, "  --kid      witness key id")
+	fmt.Fprintln(w, "  --input    signed envelope JSON file to countersign")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "options:")
+	fmt.Fprintln(w, "  --output   output file path (default: stdout; required when --json is set)")
+	fmt.Fprintln(w, "  --json     output result as JSON (for CI / automation);")
+	fmt.Fprintln(w, "             when set, writes countersignature JSON to --output (required)")
+}
+
+func printMigrateUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: veriseal migrate --privkey <path> --kid <id> --input <v1.signed.json> [options]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "required:")
+	fmt.Fprintln(w, "  --privkey  path to ed25519 private key for the v2 envelope (PKCS#8 PEM)")
+	fmt.Fprintln(w, "  --kid      key id for the v2 envelope")
+	fmt.Fprintln(w, "  --input    signed v1 envelope JSON file")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "options:")
+	fmt.Fprintln(w, "  --mode     resign: copy the v1 fields (same payload_hash) into v2 and sign again")
+	fmt.Fprintln(w, "             wrap:   sign a v2 jcs envelope whose payload is the v1 envelope")
+	fmt.Fprintln(w, "             (default: resign)")
+	fmt.Fprintln(w, "  --pubkey   public key of the original signer; verifies the v1 envelope first")
+	fmt.Fprintln(w, "  --output   output file path (default: stdout; required when --json is set)")
+	fmt.Fprintln(w, "  --json     output result as JSON (for CI / automation);")
+	fmt.Fprintln(w, "             when set, writes v2 envelope JSON to --output (required)")
+}
+
+func printAttestUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: veriseal attest --privkey <path> --kid <id> --subject <file> [--subject <file> ...] [options]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "required:")
+	fmt.Fprintln(w, "  --privkey           path to ed25519 private key (PKCS#8 PEM)")
+	fmt.Fprintln(w, "  --kid               key id of the attester")
+	fmt.Fprintln(w, "  --subject           artifact file to attest (repeatable); the path is the subject name")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "options:")
+	fmt.Fprintln(w, "  --subject-encoding  raw (default), jcs, text, cbor, yaml, ndjson, tar or zip: normalization before")
+	fmt.Fprintln(w, "                      the sha256 digest, as for payload_hash")
+	fmt.Fprintln(w, "  --trim-trailing-whitespace")
+	fmt.Fprintln(w, "                      text only: also trim trailing whitespace from every line")
+	fmt.Fprintln(w, "  --predicate-type    statement predicateType (default: https://slsa.dev/provenance/v1)")
+	fmt.Fprintln(w, "  --predicate-file    predicate JSON file")
+	fmt.Fprintln(w, "  --predicate-field   predicate field as key=value, dotted keys nest (repeatable;")
+	fmt.Fprintln(w, "                      cannot be combined with --predicate-file)")
+	fmt.Fprintln(w, "  --output            output file path (default: stdout; required when --json is set)")
+	fmt.Fprintln(w, "  --json              output result as JSON (for CI / automation);")
+	fmt.Fprintln(w, "                      when set, writes attestation envelope JSON to --output (required)")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "the in-toto Statement (v1) is signed as the attached jcs payload of the envelope; iat is set.")
+}
+
+func printVerifyAttestationUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: veriseal verify-attestation --pubkey <path> --input <attestation.json> [options]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "required:")
+	fmt.Fprintln(w, "  --pubkey          path to ed25519 public key (SPKI PEM)")
+	fmt.Fprintln(w, "  --input           attestation envelope JSON file")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "options:")
+	fmt.Fprintln(w, "  --subject         local file to check against the subject of the same name (repeatable)")
+	fmt.Fprintln(w, "  --base-dir        when --subject is not set, every subject is checked against")
+	fmt.Fprintln(w, "                    <base-dir>/<name> (default: .); names that are absolute or")
+	fmt.Fprintln(w, "                    leave <base-dir> fail")
+	fmt.Fprintln(w, "  --predicate-type  fail unless the statement has this predicateType")
+	fmt.Fprintln(w, "  --json            output result as JSON (for CI / automation)")
+}
+
+func printSignDirUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: veriseal sign-dir --privkey <path> --kid <id> --dir <dir> [options]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "required:")
+	fmt.Fprintln(w, "  --privkey  path to ed25519 private key (PKCS#8 PEM)")
+	fmt.Fprintln(w, "  --kid      key id of the signer")
+	fmt.Fprintln(w, "  --dir      directory to sign")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "options:")
+	fmt.Fprintln(w, "  --ignore   path pattern to leave out (repeatable), matched against the relative path")
+	fmt.Fprintln(w, "             and each of its elements, e.g. '*.tmp', 'node_modules', 'drafts/*.md'")
+	fmt.Fprintln(w, "  --output   output file path (default: stdout; required when --json is set)")
+	fmt.Fprintln(w, "  --json     output result as JSON (for CI / automation);")
+	fmt.Fprintln(w, "             when set, writes manifest envelope JSON to --output (required)")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "the manifest (path, size, mode and sha256 of every regular file, plus the ignore patterns)")
+	fmt.Fprintln(w, "is signed as the attached jcs payload of the envelope; iat is set.")
+}
+
+func printVerifyDirUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: veriseal verify-dir --pubkey <path> --input <manifest.json> --dir <dir> [options]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "required:")
+	fmt.Fprintln(w, "  --pubkey  path to ed25519 public key (SPKI PEM)")
+	fmt.Fprintln(w, "  --input   manifest envelope JSON file")
+	fmt.Fprintln(w, "  --dir     directory to verify")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "options:")
+	fmt.Fprintln(w, "  --ignore         path pattern to leave out on both sides, on top of the signed ones (repeatable)")
+	fmt.Fprintln(w, "  --allow-skipped  accept signed files that --ignore leaves out; they are listed as skipped")
+	fmt.Fprintln(w, "                   and fail the check without this flag")
+	fmt.Fprintln(w, "  --json           output result as JSON (for CI / automation)")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "reports every file that was added, removed or modified (size, mode or sha256).")
+}
+
+func printExportUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: veriseal export --format <format> --privkey <path> --input <signed.json> [options]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "required:")
+	fmt.Fprintln(w, "  --format        jws (compact serialization), jws-json (flattened JSON serialization)")
+	fmt.Fprintln(w, "                  cose (COSE_Sign1, CBOR) or dsse (DSSE envelope)")
+	fmt.Fprintln(w, "  --privkey       path to the ed25519 private key the envelope was signed with (PKCS#8 PEM)")
+	fmt.Fprintln(w, "  --input         signed envelope JSON file")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "options:")
+	fmt.Fprintln(w, "  --payload-file  payload file path (default: the attached payload)")
+	fmt.Fprintln(w, "  --payload-type  DSSE payloadType (default: application/json for jcs,")
+	fmt.Fprintln(w, "                  application/octet-stream otherwise)")
+	fmt.Fprintln(w, "  --output        output file path (default: stdout; required when --json is set)")
+	fmt.Fprintln(w, "  --json          output result as JSON (for CI / automation);")
+	fmt.Fprintln(w, "                  when set, writes the exported data to --output (required)")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "jws: an EdDSA JWS whose payload is the normalized payload bytes; the protected")
+	fmt.Fprintln(w, "header carries kid and the signed envelope (\"veriseal\").")
+	fmt.Fprintln(w, "cose: a tagged COSE_Sign1 whose payload is the payload_hash digest; the protected")
+	fmt.Fprintln(w, "header carries kid, the hash alg (258) and the remaining envelope fields (\"veriseal\").")
+	fmt.Fprintln(w, "--payload-file is not needed.")
+	fmt.Fprintln(w, "dsse: a DSSE envelope whose payload is the normalized payload bytes, signed over")
+	fmt.Fprintln(w, "PAE(payloadType, payload) with keyid = kid.")
+}
+
+func printImportUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: veriseal import --format <format> --input <file> [options]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "required:")
+	fmt.Fprintln(w, "  --format          jws (compact or JSON serialization), cose (COSE_Sign1) or dsse")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "options:")
+	fmt.Fprintln(w, "  --input           input file path (default: stdin)")
+	fmt.Fprintln(w, "  --pubkey          path to ed25519 public key; verifies the imported signature first")
+	fmt.Fprintln(w, "  --output          output envelope file path (default: stdout; required when --json is set)")
+	fmt.Fprintln(w, "  --payload-output  write the carried payload to this file (jws, dsse)")
+	fmt.Fprintln(w, "  --attach          embed the carried payload in the output envelope (jws, dsse)")
+	fmt.Fprintln(w, "  --kid             kid of the imported envelope (dsse; default: keyid of the first signature)")
+	fmt.Fprintln(w, "  --json            output result as JSON (for CI / automation);")
+	fmt.Fprintln(w, "                    when set, writes envelope JSON to --output (required)")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "cose and dsse messages signed outside 'veriseal export' carry no envelope signature;")
+	fmt.Fprintln(w, "the imported envelope is unsigned and a warning is printed.")
+}
+
+func printExtractUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: veriseal extract --input <envelope.json> [options]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "required:")
+	fmt.Fprintln(w, "  --input   envelope JSON file with an attached payload")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "options:")
+	fmt.Fprintln(w, "  --output  output file path (default: stdout)")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "raw payloads are written byte-exact; jcs payloads are written in their")
+	fmt.Fprintln(w, "canonical (JCS) form, which is what payload_hash covers.")
+}
+
+func printRedactUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: veriseal redact --input <signed.json> --field <ptr> [--field <ptr> ...] [options]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "required:")
+	fmt.Fprintln(w, "  --input   signed envelope with payload_encoding=sd-jcs")
+	fmt.Fprintln(w, "  --field   JSON pointer of a field to hide, with every field below it (repeatable)")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "options:")
+	fmt.Fprintln(w, "  --output  output file path (default: stdout)")
+}
+
 func printTSUsage(w io.Writer) {
 	fmt.Fprintln(w, "usage: veriseal ts <subcommand> [options]")
 	fmt.Fprintln(w)
@@ -88,7 +345,7 @@ func printTSInitUsage(w io.Writer) {
 	fmt.Fprintln(w, "required:")
 	fmt.Fprintln(w, "  --kid <id>                key id")
 	fmt.Fprintln(w, "options:")
-	fmt.Fprintln(w, "  --payload-encoding <type>  payload encoding: jcs or raw (default: jcs)")
+	fmt.Fprintln(w, "  --payload-encoding <type>  payload encoding: jcs, raw, text, cbor, yaml, ndjson, sd-jcs, tar or zip (default: jcs)")
 	fmt.Fprintln(w, "  --output <path>            output file path for envelope JSON (default: stdout)")
 	fmt.Fprintln(w, "  --json                     output result as JSON (for CI / automation);")
 	fmt.Fprintln(w, "                             when set, writes envelope JSON to --output (required)")
@@ -123,5 +380,12 @@ func printTSAuditUsage(w io.Writer) {
 	fmt.Fprintln(w, "  --input         input JSONL file (signed envelopes)")
 	fmt.Fprintln(w, "options:")
 	fmt.Fprintln(w, "  --strict-start  require ts_seq=0 and empty ts_prev on the first line")
+	fmt.Fprintln(w, "  --format        json (default), cose or dsse:")
+	fmt.Fprintln(w, "                  cose: --input is a COSE_Sign1 message (only --pubkey and --payload-file apply)")
+	fmt.Fprintln(w, "                  dsse: --input is a DSSE envelope; --pubkey accepts any matching signature,")
+	fmt.Fprintln(w, "                  --trust-store / --threshold look signers up by keyid")
+	fmt.Fprintln(w, "  --lenient       accept unknown, duplicate and case-variant fields (legacy parsing)")
+	fmt.Fprintln(w, "  --max-depth, --max-size, --max-keys, --max-string-length")
+	fmt.Fprintln(w, "                  limits on envelope JSON (default: 64, 64 MiB, 10000, 64 MiB; 0: no limit)")
 	fmt.Fprintln(w, "  --json          output result as JSON (for CI / automation)")
 }
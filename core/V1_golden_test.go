@@ -5,6 +5,7 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"os"
 	"path/filepath"
 	"testing"
 
@@ -27,7 +28,11 @@ func mustJSON(t *testing.T, v any) []byte {
 	return b
 }
 
-func TestGolden_SignBasic(t *testing.T) {
+// goldenSignSetup pins iat and the key, and returns the signing key and the
+// fixed payload of the sign golden cases.
+func goldenSignSetup(t *testing.T) (ed25519.PrivateKey, []byte) {
+	t.Helper()
+
 	// deterministic iat
 	oldNowUnix := nowUnix
 	nowUnix = func() int64 { return 1700000000 }
@@ -41,17 +46,13 @@ func TestGolden_SignBasic(t *testing.T) {
 	priv := ed25519.NewKeyFromSeed(seed)
 
 	// fixed payload
-	payload := []byte("{\"hello\":\"world\",\"n\":1}\n")
-
-	env, err := NewEnvelopeTemplateV1("test-kid", "jcs")
-	if err != nil {
-		t.Fatalf("NewEnvelopeTemplateV1: %v", err)
-	}
+	return priv, []byte("{\"hello\":\"world\",\"n\":1}\n")
+}
 
-	signed, err := SignEd25519(env, payload, priv, true)
-	if err != nil {
-		t.Fatalf("SignEd25519: %v", err)
-	}
+// goldenSign checks the unsigned canonical form, its hash and the signed
+// envelope against testdata/golden/sign/<name>.
+func goldenSign(t *testing.T, name string, signed Envelope) {
+	t.Helper()
 
 	// unsigned canonical + hash
 	unsigned := signed
@@ -65,13 +66,63 @@ func TestGolden_SignBasic(t *testing.T) {
 	if err != nil {
 		t.Fatalf("canonicalize: %v", err)
 	}
-	testutil.DiffOrUpdate(t, goldenPath("sign", "basic", "envelope.unsigned.canon.json"), append(canonBytes, '\n'))
+	testutil.DiffOrUpdate(t, goldenPath("sign", name, "envelope.unsigned.canon.json"), append(canonBytes, '\n'))
 
 	sum := sha256.Sum256(canonBytes)
 	hashB64 := base64.StdEncoding.EncodeToString(sum[:])
-	testutil.DiffOrUpdate(t, goldenPath("sign", "basic", "envelope.unsigned.hash.b64"), []byte(hashB64+"\n"))
+	testutil.DiffOrUpdate(t, goldenPath("sign", name, "envelope.unsigned.hash.b64"), []byte(hashB64+"\n"))
+
+	testutil.DiffOrUpdate(t, goldenPath("sign", name, "envelope.signed.json"), mustJSON(t, signed))
+}
+
+// TestGolden_SignBasic pins envelopes signed before payload_size existed:
+// the same bytes are produced when payload_size is left out, and the stored
+// vector still verifies.
+func TestGolden_SignBasic(t *testing.T) {
+	priv, payload := goldenSignSetup(t)
+
+	env, err := NewEnvelopeTemplateV1("test-kid", "jcs")
+	if err != nil {
+		t.Fatalf("NewEnvelopeTemplateV1: %v", err)
+	}
 
-	testutil.DiffOrUpdate(t, goldenPath("sign", "basic", "envelope.signed.json"), mustJSON(t, signed))
+	unsigned, err := prepareUnsigned(env, payload, SignOptions{SetIat: true})
+	if err != nil {
+		t.Fatalf("prepareUnsigned: %v", err)
+	}
+	unsigned.PayloadSize = nil
+	signed, err := signUnsignedEd25519(unsigned, priv)
+	if err != nil {
+		t.Fatalf("signUnsignedEd25519: %v", err)
+	}
+	goldenSign(t, "basic", signed)
+
+	stored, err := os.ReadFile(goldenPath("sign", "basic", "envelope.signed.json"))
+	if err != nil {
+		t.Fatalf("read golden: %v", err)
+	}
+	verified, err := VerifyEd25519JSON(stored, priv.Public().(ed25519.PublicKey))
+	if err != nil {
+		t.Fatalf("VerifyEd25519JSON: %v", err)
+	}
+	if err := VerifyPayloadHash(verified, payload); err != nil {
+		t.Fatalf("VerifyPayloadHash: %v", err)
+	}
+}
+
+func TestGolden_SignPayloadSize(t *testing.T) {
+	priv, payload := goldenSignSetup(t)
+
+	env, err := NewEnvelopeTemplateV1("test-kid", "jcs")
+	if err != nil {
+		t.Fatalf("NewEnvelopeTemplateV1: %v", err)
+	}
+
+	signed, err := SignEd25519(env, payload, priv, true)
+	if err != nil {
+		t.Fatalf("SignEd25519: %v", err)
+	}
+	goldenSign(t, "payload_size", signed)
 }
 
 func TestGolden_TimeseriesBasic(t *testing.T) {
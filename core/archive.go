@@ -0,0 +1,364 @@
+package core
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/na0h/veriseal/canonical"
+)
+
+// Archive entry types in the canonical listing.
+const (
+	EntryFile     = "file"
+	EntryDir      = "dir"
+	EntrySymlink  = "symlink"
+	EntryHardlink = "hardlink"
+)
+
+// ArchiveEntry is one entry of the canonical listing of a "tar" or "zip"
+// payload. Mode holds the permission bits (with setuid, setgid and sticky)
+// in octal; symlinks have none. Size and SHA256 (hex) describe the content
+// of files, Target the link target of links. Timestamps, ownership and the
+// order of the entries are not recorded.
+type ArchiveEntry struct {
+	Type   string `json:"type"`
+	Mode   string `json:"mode,omitempty"`
+	Size   *int64 `json:"size,omitempty"`
+	SHA256 string `json:"sha256,omitempty"`
+	Target string `json:"target,omitempty"`
+}
+
+// MaxLinkTarget caps the link target of an archive entry, in bytes.
+const MaxLinkTarget = 4096
+
+// EntryMismatch is one archive entry that differs from
+// payload_entry_hashes; Reason is RecordChanged, RecordAdded or
+// RecordMissing.
+type EntryMismatch struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// NormalizeArchive returns the canonical listing of a tar (optionally
+// gzip-compressed) or zip payload: the JCS form of {"entries": {path:
+// ArchiveEntry}}. Paths are relative, "/"-separated, without a leading
+// "./" or a trailing "/"; two entries with the same path are rejected.
+func NormalizeArchive(payload []byte, payloadEncoding string) ([]byte, error) {
+	entries, err := archiveEntries(bytes.NewReader(payload), int64(len(payload)), payloadEncoding)
+	if err != nil {
+		return nil, err
+	}
+	return archiveListing(entries)
+}
+
+// ArchiveEntryHashes returns the payload_entry_hashes of a tar or zip
+// payload: the sha256 (base64) of the JCS form of each entry, by path.
+func ArchiveEntryHashes(payload []byte, payloadEncoding string) (map[string]string, error) {
+	entries, err := archiveEntries(bytes.NewReader(payload), int64(len(payload)), payloadEncoding)
+	if err != nil {
+		return nil, err
+	}
+	return entryHashes(entries)
+}
+
+// ExplainArchiveMismatch lists the entries of payload that differ from the
+// envelope's payload_entry_hashes, sorted by path.
+func ExplainArchiveMismatch(envelope Envelope, payload []byte) ([]EntryMismatch, error) {
+	if !isArchiveEncoding(envelope.PayloadEncoding) {
+		return nil, fmt.Errorf("cannot explain payload_encoding=%s (tar or zip only)", envelope.PayloadEncoding)
+	}
+	want := envelope.PayloadEntryHashes
+	if want == nil {
+		return nil, fmt.Errorf("envelope has no payload_entry_hashes (sign with record hashes to locate differing entries)")
+	}
+	got, err := ArchiveEntryHashes(payload, envelope.PayloadEncoding)
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []EntryMismatch
+	for _, p := range slices.Sorted(maps.Keys(want)) {
+		h, ok := got[p]
+		switch {
+		case !ok:
+			diffs = append(diffs, EntryMismatch{Path: p, Reason: RecordMissing})
+		case h != want[p]:
+			diffs = append(diffs, EntryMismatch{Path: p, Reason: RecordChanged})
+		}
+	}
+	for _, p := range slices.Sorted(maps.Keys(got)) {
+		if _, ok := want[p]; !ok {
+			diffs = append(diffs, EntryMismatch{Path: p, Reason: RecordAdded})
+		}
+	}
+	slices.SortStableFunc(diffs, func(a, b EntryMismatch) int { return strings.Compare(a.Path, b.Path) })
+	return diffs, nil
+}
+
+// hashTarStream hashes the listing of a tar payload read from r and, when
+// asked, returns its payload_entry_hashes from the same pass.
+func hashTarStream(r io.Reader, withEntries bool) (string, int64, map[string]string, error) {
+	entries, err := archiveEntries(r, 0, V1PayloadEncodingTar)
+	if err != nil {
+		return "", 0, nil, err
+	}
+	norm, err := archiveListing(entries)
+	if err != nil {
+		return "", 0, nil, err
+	}
+	var hashes map[string]string
+	if withEntries {
+		hashes, err = entryHashes(entries)
+		if err != nil {
+			return "", 0, nil, err
+		}
+	}
+	return hashNormalizedPayload(norm), int64(len(norm)), hashes, nil
+}
+
+func isArchiveEncoding(encoding string) bool {
+	return encoding == V1PayloadEncodingTar || encoding == V1PayloadEncodingZip
+}
+
+// archiveEntries reads the entries of a tar or zip archive. tar archives
+// are read in one pass, so r may be a stream (size is then unused); zip
+// archives need the central directory at the end, so r must be an
+// io.ReaderAt of the given size.
+func archiveEntries(r io.Reader, size int64, encoding string) (map[string]ArchiveEntry, error) {
+	var entries map[string]ArchiveEntry
+	var err error
+	switch encoding {
+	case V1PayloadEncodingTar:
+		entries, err = tarEntries(r)
+	case V1PayloadEncodingZip:
+		ra, ok := r.(io.ReaderAt)
+		if !ok {
+			return nil, fmt.Errorf("payload_encoding=zip needs random access to the payload")
+		}
+		entries, err = zipEntries(ra, size)
+	default:
+		return nil, fmt.Errorf("unsupported payload_encoding: %s", encoding)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("payload_encoding=%s but payload is not a valid %s archive: %w", encoding, encoding, err)
+	}
+	return entries, nil
+}
+
+func tarEntries(r io.Reader) (map[string]ArchiveEntry, error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		r = zr
+	} else {
+		r = br
+	}
+
+	entries := map[string]ArchiveEntry{}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var e ArchiveEntry
+		switch hdr.Typeflag {
+		case tar.TypeXGlobalHeader:
+			continue
+		case tar.TypeReg:
+			e, err = contentEntry(tr)
+		case tar.TypeDir:
+			e.Type = EntryDir
+		case tar.TypeSymlink:
+			e = ArchiveEntry{Type: EntrySymlink, Target: hdr.Linkname}
+		case tar.TypeLink:
+			e = ArchiveEntry{Type: EntryHardlink}
+			e.Target, err = entryPath(hdr.Linkname)
+		default:
+			return nil, fmt.Errorf("%s: unsupported entry type %q", hdr.Name, hdr.Typeflag)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", hdr.Name, err)
+		}
+		if e.Type != EntrySymlink {
+			e.Mode = modeString(hdr.FileInfo().Mode())
+		}
+		if err := addEntry(entries, hdr.Name, e); err != nil {
+			return nil, err
+		}
+	}
+	// Drain the end-of-archive padding, so a stream is read to its end (and
+	// the gzip checksum is checked).
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func zipEntries(r io.ReaderAt, size int64) (map[string]ArchiveEntry, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	entries := map[string]ArchiveEntry{}
+	for _, f := range zr.File {
+		mode := f.Mode()
+		var e ArchiveEntry
+		switch {
+		case mode.IsDir():
+			e = ArchiveEntry{Type: EntryDir, Mode: modeString(mode)}
+		case mode&fs.ModeSymlink != 0:
+			target, err := readZipFile(f)
+			if err != nil {
+				return nil, err
+			}
+			e = ArchiveEntry{Type: EntrySymlink, Target: string(target)}
+		case mode.IsRegular():
+			rc, err := f.Open()
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", f.Name, err)
+			}
+			e, err = contentEntry(rc)
+			rc.Close() //nolint:errcheck
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", f.Name, err)
+			}
+			e.Mode = modeString(mode)
+		default:
+			return nil, fmt.Errorf("%s: unsupported entry mode %s", f.Name, mode)
+		}
+		if err := addEntry(entries, f.Name, e); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", f.Name, err)
+	}
+	defer rc.Close() //nolint:errcheck
+	// one byte more, so that addEntry sees a target that is too long
+	b, err := io.ReadAll(io.LimitReader(rc, MaxLinkTarget+1))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", f.Name, err)
+	}
+	return b, nil
+}
+
+func contentEntry(r io.Reader) (ArchiveEntry, error) {
+	h := sha256.New()
+	n, err := io.Copy(h, r)
+	if err != nil {
+		return ArchiveEntry{}, err
+	}
+	return ArchiveEntry{Type: EntryFile, Size: &n, SHA256: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+func addEntry(entries map[string]ArchiveEntry, name string, e ArchiveEntry) error {
+	p, err := entryPath(name)
+	if err != nil {
+		return err
+	}
+	if !utf8.ValidString(p) || !utf8.ValidString(e.Target) {
+		return fmt.Errorf("%q: entry name is not valid UTF-8", name)
+	}
+	if len(e.Target) > MaxLinkTarget {
+		return fmt.Errorf("%s: link target exceeds %d bytes", p, MaxLinkTarget)
+	}
+	if p == "." {
+		// the archive root ("./"), written by 'tar -C dir .'
+		if e.Type == EntryDir {
+			return nil
+		}
+		return fmt.Errorf("invalid entry path: %q", name)
+	}
+	if _, dup := entries[p]; dup {
+		return fmt.Errorf("duplicate entry: %s", p)
+	}
+	entries[p] = e
+	return nil
+}
+
+// entryPath normalizes an entry name: no leading "./", no trailing "/".
+func entryPath(name string) (string, error) {
+	p := strings.TrimSuffix(name, "/")
+	for strings.HasPrefix(p, "./") {
+		p = p[2:]
+	}
+	if p == "" {
+		p = "."
+	}
+	if !fs.ValidPath(p) {
+		return "", fmt.Errorf("invalid entry path: %q", name)
+	}
+	return p, nil
+}
+
+func modeString(m fs.FileMode) string {
+	bits := uint32(m.Perm())
+	if m&fs.ModeSetuid != 0 {
+		bits |= 0o4000
+	}
+	if m&fs.ModeSetgid != 0 {
+		bits |= 0o2000
+	}
+	if m&fs.ModeSticky != 0 {
+		bits |= 0o1000
+	}
+	return fmt.Sprintf("%04o", bits)
+}
+
+// archiveListing returns the JCS form of the listing. The JSON is built
+// here rather than read from the payload, so canonical.DefaultLimits (100000
+// members per object) do not apply: an archive may hold any number of
+// entries.
+func archiveListing(entries map[string]ArchiveEntry) ([]byte, error) {
+	b, err := json.Marshal(map[string]any{"entries": entries})
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := canonical.CanonicalizeStreamWithLimits(&buf, bytes.NewReader(b), canonical.Limits{}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func entryHashes(entries map[string]ArchiveEntry) (map[string]string, error) {
+	hashes := make(map[string]string, len(entries))
+	for p, e := range entries {
+		b, err := json.Marshal(e)
+		if err != nil {
+			return nil, err
+		}
+		c, err := canonical.Canonicalize(b)
+		if err != nil {
+			return nil, err
+		}
+		hashes[p] = hashNormalizedPayload(c)
+	}
+	return hashes, nil
+}
@@ -0,0 +1,314 @@
+package core
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// -----------------------------------------------------------------------------
+// tar / zip payloads
+// -----------------------------------------------------------------------------
+
+func baseEnvelopeTar() Envelope {
+	env, err := NewEnvelopeTemplateV1("demo-1", V1PayloadEncodingTar)
+	if err != nil {
+		panic(err)
+	}
+	return env
+}
+
+type entryForTest struct {
+	name string
+	body string
+	mode fs.FileMode
+}
+
+func siteEntriesForTest() []entryForTest {
+	return []entryForTest{
+		{name: "site/", mode: fs.ModeDir | 0o755},
+		{name: "site/index.html", body: "<h1>hi</h1>", mode: 0o644},
+		{name: "site/run.sh", body: "#!/bin/sh", mode: 0o755},
+		{name: "site/latest", body: "index.html", mode: fs.ModeSymlink | 0o777},
+	}
+}
+
+func tarForTest(t *testing.T, entries []entryForTest, mtime time.Time, uid int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Mode: int64(e.mode.Perm()), ModTime: mtime, Uid: uid, Uname: "u"}
+		switch {
+		case e.mode.IsDir():
+			hdr.Typeflag = tar.TypeDir
+		case e.mode&fs.ModeSymlink != 0:
+			hdr.Typeflag = tar.TypeSymlink
+			hdr.Linkname = e.body
+		default:
+			hdr.Typeflag = tar.TypeReg
+			hdr.Size = int64(len(e.body))
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			if _, err := io.WriteString(tw, e.body); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func zipForTest(t *testing.T, entries []entryForTest, mtime time.Time) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		hdr := &zip.FileHeader{Name: e.name, Method: zip.Deflate, Modified: mtime}
+		hdr.SetMode(e.mode)
+		w, err := zw.CreateHeader(hdr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(w, e.body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func gzipForTest(t *testing.T, b []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(b); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestArchive_Tar_IgnoresMtimeOwnerOrder(t *testing.T) {
+	entries := siteEntriesForTest()
+	a := tarForTest(t, entries, time.Unix(1_700_000_000, 0), 1000)
+
+	slices.Reverse(entries)
+	b := tarForTest(t, entries, time.Unix(1_800_000_000, 0), 0)
+
+	ha, err := ComputePayloadHash(a, V1PayloadEncodingTar)
+	if err != nil {
+		t.Fatalf("ComputePayloadHash: %v", err)
+	}
+	for _, p := range [][]byte{b, gzipForTest(t, b)} {
+		hb, err := ComputePayloadHash(p, V1PayloadEncodingTar)
+		if err != nil {
+			t.Fatalf("ComputePayloadHash: %v", err)
+		}
+		if ha != hb {
+			t.Fatalf("hash mismatch: %s vs %s", ha, hb)
+		}
+	}
+
+	norm, err := NormalizeArchive(a, V1PayloadEncodingTar)
+	if err != nil {
+		t.Fatalf("NormalizeArchive: %v", err)
+	}
+	var listing struct {
+		Entries map[string]ArchiveEntry `json:"entries"`
+	}
+	if err := json.Unmarshal(norm, &listing); err != nil {
+		t.Fatal(err)
+	}
+	if e := listing.Entries["site/latest"]; e.Type != EntrySymlink || e.Target != "index.html" || e.Mode != "" {
+		t.Fatalf("unexpected symlink entry: %+v", e)
+	}
+	if e := listing.Entries["site/run.sh"]; e.Type != EntryFile || e.Mode != "0755" || e.Size == nil || *e.Size != 9 {
+		t.Fatalf("unexpected file entry: %+v", e)
+	}
+}
+
+func TestArchive_Zip_IgnoresMtimeOrder(t *testing.T) {
+	entries := siteEntriesForTest()
+	a := zipForTest(t, entries, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	slices.Reverse(entries)
+	b := zipForTest(t, entries, time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC))
+
+	ha, err := ComputePayloadHash(a, V1PayloadEncodingZip)
+	if err != nil {
+		t.Fatalf("ComputePayloadHash: %v", err)
+	}
+	hb, err := ComputePayloadHash(b, V1PayloadEncodingZip)
+	if err != nil {
+		t.Fatalf("ComputePayloadHash: %v", err)
+	}
+	if ha != hb {
+		t.Fatalf("hash mismatch: %s vs %s", ha, hb)
+	}
+
+	// tar and zip with the same entries share the listing
+	ht, err := ComputePayloadHash(tarForTest(t, entries, time.Unix(0, 0), 0), V1PayloadEncodingTar)
+	if err != nil {
+		t.Fatalf("ComputePayloadHash: %v", err)
+	}
+	if ha != ht {
+		t.Fatalf("hash mismatch: %s vs %s", ha, ht)
+	}
+}
+
+func TestArchive_Explain_Entries(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Unix(1_700_000_000, 0)
+	signedTar := tarForTest(t, siteEntriesForTest(), mtime, 0)
+
+	signed, err := SignEd25519WithOptions(baseEnvelopeTar(), signedTar, priv, SignOptions{RecordHashes: true})
+	if err != nil {
+		t.Fatalf("SignEd25519WithOptions: %v", err)
+	}
+	if len(signed.PayloadEntryHashes) != 4 {
+		t.Fatalf("want 4 payload_entry_hashes, got %v", signed.PayloadEntryHashes)
+	}
+
+	entries := siteEntriesForTest()
+	entries[1].body = "<h1>bye</h1>"       // site/index.html changed
+	entries[2].mode = 0o644                // site/run.sh mode changed
+	entries = slices.Delete(entries, 3, 4) // site/latest missing
+	entries = append(entries, entryForTest{name: "site/new.css", body: "a{}", mode: 0o644})
+	modified := tarForTest(t, entries, mtime, 0)
+
+	if err := VerifyPayloadHash(signed, modified); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+	diffs, err := ExplainArchiveMismatch(signed, modified)
+	if err != nil {
+		t.Fatalf("ExplainArchiveMismatch: %v", err)
+	}
+	want := []EntryMismatch{
+		{Path: "site/index.html", Reason: RecordChanged},
+		{Path: "site/latest", Reason: RecordMissing},
+		{Path: "site/new.css", Reason: RecordAdded},
+		{Path: "site/run.sh", Reason: RecordChanged},
+	}
+	if !reflect.DeepEqual(diffs, want) {
+		t.Fatalf("got  %+v\nwant %+v", diffs, want)
+	}
+}
+
+func TestArchive_StreamSignAndVerify_OK(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := gzipForTest(t, tarForTest(t, siteEntriesForTest(), time.Unix(0, 0), 0))
+
+	signed, err := SignReader(baseEnvelopeTar(), bytes.NewReader(payload), priv, SignOptions{RecordHashes: true})
+	if err != nil {
+		t.Fatalf("SignReader: %v", err)
+	}
+	if len(signed.PayloadEntryHashes) != 4 {
+		t.Fatalf("want 4 payload_entry_hashes, got %v", signed.PayloadEntryHashes)
+	}
+	if err := VerifyEd25519(signed, pub); err != nil {
+		t.Fatalf("VerifyEd25519: %v", err)
+	}
+	if err := VerifyPayloadHash(signed, payload); err != nil {
+		t.Fatalf("VerifyPayloadHash: %v", err)
+	}
+
+	vr, err := NewVerifyingReader(signed, bytes.NewReader(payload))
+	if err != nil {
+		t.Fatalf("NewVerifyingReader: %v", err)
+	}
+	got, err := io.ReadAll(vr)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Fatalf("payload bytes changed")
+	}
+}
+
+func TestArchive_Invalid_Fail(t *testing.T) {
+	entries := siteEntriesForTest()
+	entries = append(entries, entryForTest{name: "./site/run.sh", body: "other", mode: 0o755})
+	if _, err := ComputePayloadHash(tarForTest(t, entries, time.Unix(0, 0), 0), V1PayloadEncodingTar); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+
+	bad := []entryForTest{{name: "../escape", body: "x", mode: 0o644}}
+	if _, err := ComputePayloadHash(tarForTest(t, bad, time.Unix(0, 0), 0), V1PayloadEncodingTar); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+
+	if _, err := ComputePayloadHash([]byte("not an archive"), V1PayloadEncodingZip); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+
+	env := baseEnvelopeJCS()
+	env.PayloadEntryHashes = map[string]string{"a": "x"}
+	if err := ValidateEnvelopeV1(env); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+}
+
+func TestArchive_LongLinkTarget_Fail(t *testing.T) {
+	for _, n := range []int{MaxLinkTarget, MaxLinkTarget + 1} {
+		entries := []entryForTest{{name: "link", body: strings.Repeat("a", n), mode: fs.ModeSymlink | 0o777}}
+		_, zipErr := ComputePayloadHash(zipForTest(t, entries, time.Unix(0, 0)), V1PayloadEncodingZip)
+		_, tarErr := ComputePayloadHash(tarForTest(t, entries, time.Unix(0, 0), 0), V1PayloadEncodingTar)
+		if n <= MaxLinkTarget && (zipErr != nil || tarErr != nil) {
+			t.Fatalf("target of %d bytes: zip: %v, tar: %v", n, zipErr, tarErr)
+		}
+		if n > MaxLinkTarget && (zipErr == nil || tarErr == nil) {
+			t.Fatalf("target of %d bytes: want errors, got zip: %v, tar: %v", n, zipErr, tarErr)
+		}
+	}
+}
+
+func TestArchive_Listing_ManyEntries_OK(t *testing.T) {
+	// more members than canonical.DefaultLimits allows in one object
+	const n = 100_001
+	size := int64(1)
+	entries := make(map[string]ArchiveEntry, n)
+	for i := range n {
+		entries["f/"+strconv.Itoa(i)] = ArchiveEntry{Type: EntryFile, Mode: "0644", Size: &size, SHA256: strings.Repeat("0", 64)}
+	}
+	b, err := archiveListing(entries)
+	if err != nil {
+		t.Fatalf("archiveListing: %v", err)
+	}
+	var listing struct {
+		Entries map[string]ArchiveEntry `json:"entries"`
+	}
+	if err := json.Unmarshal(b, &listing); err != nil {
+		t.Fatal(err)
+	}
+	if len(listing.Entries) != n {
+		t.Fatalf("got %d entries, want %d", len(listing.Entries), n)
+	}
+}
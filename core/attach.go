@@ -0,0 +1,76 @@
+package core
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/na0h/veriseal/canonical"
+)
+
+// AttachPayload embeds the payload into the envelope. "sd-jcs" payloads
+// are stored inline in their canonical form. A "jcs" payload is stored
+// inline only when it already is in canonical form, so that the inline JSON
+// is the payload bytes; any other payload, including JSON that is not
+// canonical, is stored as a base64 string of the exact bytes.
+func AttachPayload(envelope Envelope, payload []byte) (Envelope, error) {
+	switch envelope.PayloadEncoding {
+	case "":
+		return Envelope{}, fmt.Errorf("missing payload_encoding")
+	case V1PayloadEncodingJCS, V1PayloadEncodingSD:
+		b, err := canonical.Canonicalize(payload)
+		if err != nil {
+			return Envelope{}, fmt.Errorf("payload_encoding=%s but payload is not valid JSON", envelope.PayloadEncoding)
+		}
+		if envelope.PayloadEncoding == V1PayloadEncodingSD || bytes.Equal(b, payload) {
+			envelope.Payload = b
+			return envelope, nil
+		}
+	}
+	b, err := json.Marshal(base64.StdEncoding.EncodeToString(payload))
+	if err != nil {
+		return Envelope{}, err
+	}
+	envelope.Payload = b
+	return envelope, nil
+}
+
+// AttachedPayload returns the embedded payload bytes: the canonical JSON of
+// an inline "jcs" or "sd-jcs" payload, the decoded bytes of a base64 one.
+func AttachedPayload(envelope Envelope) ([]byte, error) {
+	if len(envelope.Payload) == 0 {
+		return nil, fmt.Errorf("no attached payload")
+	}
+
+	switch envelope.PayloadEncoding {
+	case "":
+		return nil, fmt.Errorf("missing payload_encoding")
+	case V1PayloadEncodingSD:
+		return canonicalAttached(envelope.Payload)
+	case V1PayloadEncodingJCS:
+		// canonical JSON is an object or array, never a string
+		if envelope.Payload[0] != '"' {
+			return canonicalAttached(envelope.Payload)
+		}
+		fallthrough
+	default:
+		var s string
+		if err := json.Unmarshal(envelope.Payload, &s); err != nil {
+			return nil, fmt.Errorf("invalid attached payload: want base64 string")
+		}
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid attached payload (base64 decode failed)")
+		}
+		return b, nil
+	}
+}
+
+func canonicalAttached(payload json.RawMessage) ([]byte, error) {
+	b, err := canonical.Canonicalize(payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid attached payload: %w", err)
+	}
+	return b, nil
+}
@@ -0,0 +1,154 @@
+package core
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"testing"
+)
+
+// -----------------------------------------------------------------------------
+// V1: Attached payload
+// -----------------------------------------------------------------------------
+
+func TestV1_AttachPayload_JCS_RoundTrip_OK(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := []byte(`{"b":2,"a":1}`)
+	signed, err := SignEd25519(baseEnvelopeJCS(), payload, priv, false)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	attached, err := AttachPayload(signed, payload)
+	if err != nil {
+		t.Fatalf("attach: %v", err)
+	}
+	// not canonical: kept byte-exact as base64
+	if attached.Payload[0] != '"' {
+		t.Fatalf("unexpected inline payload: %s", attached.Payload)
+	}
+
+	b, err := json.Marshal(attached)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// attaching does not change what is signed
+	env, err := VerifyEd25519JSON(b, pub)
+	if err != nil {
+		t.Fatalf("verify sig: %v", err)
+	}
+
+	got, err := AttachedPayload(env)
+	if err != nil {
+		t.Fatalf("AttachedPayload: %v", err)
+	}
+	if err := VerifyPayloadHash(env, got); err != nil {
+		t.Fatalf("verify payload hash: %v", err)
+	}
+}
+
+func TestV1_AttachPayload_JCS_ByteExact_OK(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		payload string
+		inline  bool
+	}{
+		{payload: `{"a":1,"b":[true,null]}`, inline: true},
+		{payload: "{\n  \"b\": 2,\n  \"a\": 1.0\n}\n", inline: false},
+	} {
+		payload := []byte(tc.payload)
+		signed, err := SignEd25519(baseEnvelopeJCS(), payload, priv, false)
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		attached, err := AttachPayload(signed, payload)
+		if err != nil {
+			t.Fatalf("attach: %v", err)
+		}
+		if inline := string(attached.Payload) == tc.payload; inline != tc.inline {
+			t.Fatalf("%q: inline = %v, want %v", tc.payload, inline, tc.inline)
+		}
+
+		b, err := json.Marshal(attached)
+		if err != nil {
+			t.Fatal(err)
+		}
+		env, err := ParseEnvelopeStrict(b)
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		got, err := AttachedPayload(env)
+		if err != nil {
+			t.Fatalf("AttachedPayload: %v", err)
+		}
+		if !bytes.Equal(got, payload) {
+			t.Fatalf("want %q, got %q", payload, got)
+		}
+		if err := VerifyPayloadHash(env, got); err != nil {
+			t.Fatalf("verify payload hash: %v", err)
+		}
+	}
+}
+
+func TestV1_AttachPayload_Raw_ByteExact_OK(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := []byte{0x00, 0xff, '\r', '\n'}
+	signed, err := SignEd25519(baseEnvelopeRaw(), payload, priv, false)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	attached, err := AttachPayload(signed, payload)
+	if err != nil {
+		t.Fatalf("attach: %v", err)
+	}
+
+	got, err := AttachedPayload(attached)
+	if err != nil {
+		t.Fatalf("AttachedPayload: %v", err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Fatalf("want %x, got %x", payload, got)
+	}
+}
+
+func TestV1_AttachedPayload_Tampered_Fail(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed, err := SignEd25519(baseEnvelopeJCS(), []byte(`{"a":1}`), priv, false)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	attached, err := AttachPayload(signed, []byte(`{"a":2}`))
+	if err != nil {
+		t.Fatalf("attach: %v", err)
+	}
+
+	got, err := AttachedPayload(attached)
+	if err != nil {
+		t.Fatalf("AttachedPayload: %v", err)
+	}
+	if err := VerifyPayloadHash(attached, got); err == nil {
+		t.Fatalf("want payload hash mismatch, got nil")
+	}
+}
+
+func TestV1_AttachedPayload_Missing_Fail(t *testing.T) {
+	if _, err := AttachedPayload(baseEnvelopeJCS()); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+}
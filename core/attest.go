@@ -0,0 +1,206 @@
+package core
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/na0h/veriseal/canonical"
+)
+
+const (
+	InTotoStatementTypeV1 = "https://in-toto.io/Statement/v1"
+	SLSAProvenanceV1      = "https://slsa.dev/provenance/v1"
+
+	// SubjectAnnotationPayloadEncoding records how a subject was normalized
+	// before hashing when it is not raw.
+	SubjectAnnotationPayloadEncoding = "veriseal_payload_encoding"
+	// SubjectAnnotationPayloadText records, as JSON, the text normalization
+	// steps of a text subject (see TextNormalization).
+	SubjectAnnotationPayloadText = "veriseal_payload_text"
+)
+
+// Statement is an in-toto attestation Statement (v1).
+type Statement struct {
+	Type          string          `json:"_type"`
+	Subject       []Subject       `json:"subject"`
+	PredicateType string          `json:"predicateType"`
+	Predicate     json.RawMessage `json:"predicate,omitempty"`
+}
+
+// Subject is an in-toto ResourceDescriptor identifying one artifact.
+type Subject struct {
+	Name        string            `json:"name"`
+	Digest      map[string]string `json:"digest"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// NewSubject describes an artifact by its sha256 digest. The bytes are
+// normalized with the payload encoding first, exactly as ComputePayloadHash
+// does; non-raw encodings are recorded in the subject annotations. text
+// subjects are made with NewTextSubject.
+func NewSubject(name string, payloadBytes []byte, encoding string) (Subject, error) {
+	if name == "" {
+		return Subject{}, fmt.Errorf("missing subject name")
+	}
+	if encoding == V1PayloadEncodingText {
+		return Subject{}, fmt.Errorf("text subjects need their normalization steps (see NewTextSubject)")
+	}
+	norm, err := NormalizePayloadBytes(payloadBytes, encoding)
+	if err != nil {
+		return Subject{}, err
+	}
+
+	s := newSubject(name, norm)
+	if encoding != V1PayloadEncodingRaw {
+		s.Annotations = map[string]string{SubjectAnnotationPayloadEncoding: encoding}
+	}
+	return s, nil
+}
+
+// NewTextSubject is NewSubject for payload_encoding=text: the bytes are
+// normalized with t, which is recorded in the subject annotations next to
+// the encoding.
+func NewTextSubject(name string, payloadBytes []byte, t TextNormalization) (Subject, error) {
+	if name == "" {
+		return Subject{}, fmt.Errorf("missing subject name")
+	}
+	norm, err := NormalizeText(payloadBytes, t)
+	if err != nil {
+		return Subject{}, err
+	}
+	steps, err := json.Marshal(t)
+	if err != nil {
+		return Subject{}, err
+	}
+
+	s := newSubject(name, norm)
+	s.Annotations = map[string]string{
+		SubjectAnnotationPayloadEncoding: V1PayloadEncodingText,
+		SubjectAnnotationPayloadText:     string(steps),
+	}
+	return s, nil
+}
+
+func newSubject(name string, norm []byte) Subject {
+	sum := sha256.Sum256(norm)
+	return Subject{
+		Name:   name,
+		Digest: map[string]string{V1PayloadHashAlgSHA256: hex.EncodeToString(sum[:])},
+	}
+}
+
+// VerifySubject checks that payloadBytes match the subject's sha256 digest.
+func VerifySubject(s Subject, payloadBytes []byte) error {
+	want, ok := s.Digest[V1PayloadHashAlgSHA256]
+	if !ok {
+		return fmt.Errorf("subject %s: missing sha256 digest", s.Name)
+	}
+	norm, err := normalizeSubject(s, payloadBytes)
+	if err != nil {
+		return fmt.Errorf("subject %s: %w", s.Name, err)
+	}
+	sum := sha256.Sum256(norm)
+	if hex.EncodeToString(sum[:]) != want {
+		return fmt.Errorf("subject %s: digest mismatch", s.Name)
+	}
+	return nil
+}
+
+// normalizeSubject normalizes payloadBytes as recorded in the subject
+// annotations.
+func normalizeSubject(s Subject, payloadBytes []byte) ([]byte, error) {
+	encoding := V1PayloadEncodingRaw
+	if e, ok := s.Annotations[SubjectAnnotationPayloadEncoding]; ok {
+		encoding = e
+	}
+	if encoding != V1PayloadEncodingText {
+		return NormalizePayloadBytes(payloadBytes, encoding)
+	}
+
+	steps, ok := s.Annotations[SubjectAnnotationPayloadText]
+	if !ok {
+		return nil, fmt.Errorf("missing %s annotation (required for text subjects)", SubjectAnnotationPayloadText)
+	}
+	var t TextNormalization
+	dec := json.NewDecoder(strings.NewReader(steps))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&t); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %w", SubjectAnnotationPayloadText, err)
+	}
+	return NormalizeText(payloadBytes, t)
+}
+
+// ValidateStatement checks the fields every in-toto v1 Statement needs.
+func ValidateStatement(st Statement) error {
+	if st.Type != InTotoStatementTypeV1 {
+		return fmt.Errorf("unsupported statement _type: %s", st.Type)
+	}
+	if len(st.Subject) == 0 {
+		return fmt.Errorf("statement has no subject")
+	}
+	for i, s := range st.Subject {
+		if s.Name == "" {
+			return fmt.Errorf("subject %d: missing name", i)
+		}
+		if len(s.Digest) == 0 {
+			return fmt.Errorf("subject %s: missing digest", s.Name)
+		}
+	}
+	if st.PredicateType == "" {
+		return fmt.Errorf("missing predicateType")
+	}
+	return nil
+}
+
+// SignAttestationEd25519 signs the Statement as the attached jcs payload of
+// a new envelope; iat is always set.
+func SignAttestationEd25519(st Statement, kid string, priv ed25519.PrivateKey) (Envelope, error) {
+	if err := ValidateStatement(st); err != nil {
+		return Envelope{}, err
+	}
+	b, err := json.Marshal(st)
+	if err == nil {
+		// canonical, so that it is attached as inline JSON
+		b, err = canonical.Canonicalize(b)
+	}
+	if err != nil {
+		return Envelope{}, err
+	}
+
+	env, err := NewEnvelopeTemplateV1(kid, V1PayloadEncodingJCS)
+	if err != nil {
+		return Envelope{}, err
+	}
+	signed, err := SignEd25519(env, b, priv, true)
+	if err != nil {
+		return Envelope{}, err
+	}
+	return AttachPayload(signed, b)
+}
+
+// AttestationStatement returns the Statement attached to an attestation
+// envelope. It does not verify the envelope signature.
+func AttestationStatement(envelope Envelope) (Statement, error) {
+	if envelope.PayloadEncoding != V1PayloadEncodingJCS {
+		return Statement{}, fmt.Errorf("attestation: unexpected payload_encoding: %s", envelope.PayloadEncoding)
+	}
+	b, err := AttachedPayload(envelope)
+	if err != nil {
+		return Statement{}, fmt.Errorf("attestation: %w", err)
+	}
+	if err := VerifyPayloadHash(envelope, b); err != nil {
+		return Statement{}, fmt.Errorf("attestation: %w", err)
+	}
+	var st Statement
+	if err := json.Unmarshal(b, &st); err != nil {
+		return Statement{}, fmt.Errorf("attestation: %w", err)
+	}
+	if err := ValidateStatement(st); err != nil {
+		return Statement{}, fmt.Errorf("attestation: %w", err)
+	}
+	return st, nil
+}
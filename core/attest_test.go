@@ -0,0 +1,129 @@
+package core
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// -----------------------------------------------------------------------------
+// in-toto attestation
+// -----------------------------------------------------------------------------
+
+func TestAttest_SignAndVerifySubjects_OK(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bin := []byte("release binary")
+	manifest := []byte(`{"b":2,"a":1}`)
+	s1, err := NewSubject("app.bin", bin, V1PayloadEncodingRaw)
+	if err != nil {
+		t.Fatalf("NewSubject: %v", err)
+	}
+	s2, err := NewSubject("manifest.json", manifest, V1PayloadEncodingJCS)
+	if err != nil {
+		t.Fatalf("NewSubject: %v", err)
+	}
+	if s1.Digest["sha256"] == "" || s1.Annotations != nil {
+		t.Fatalf("unexpected subject: %+v", s1)
+	}
+	if s2.Annotations[SubjectAnnotationPayloadEncoding] != V1PayloadEncodingJCS {
+		t.Fatalf("unexpected subject: %+v", s2)
+	}
+
+	st := Statement{
+		Type:          InTotoStatementTypeV1,
+		Subject:       []Subject{s1, s2},
+		PredicateType: SLSAProvenanceV1,
+		Predicate:     json.RawMessage(`{"buildDefinition":{"buildType":"make"}}`),
+	}
+	env, err := SignAttestationEd25519(st, "builder-1", priv)
+	if err != nil {
+		t.Fatalf("SignAttestationEd25519: %v", err)
+	}
+	if env.Iat == nil {
+		t.Fatalf("want iat set")
+	}
+
+	b, err := json.Marshal(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verified, err := VerifyEd25519JSON(b, pub)
+	if err != nil {
+		t.Fatalf("VerifyEd25519JSON: %v", err)
+	}
+	got, err := AttestationStatement(verified)
+	if err != nil {
+		t.Fatalf("AttestationStatement: %v", err)
+	}
+	if got.PredicateType != SLSAProvenanceV1 || len(got.Subject) != 2 {
+		t.Fatalf("unexpected statement: %+v", got)
+	}
+
+	if err := VerifySubject(got.Subject[0], bin); err != nil {
+		t.Fatalf("VerifySubject: %v", err)
+	}
+	// jcs subjects match regardless of formatting
+	if err := VerifySubject(got.Subject[1], []byte(`{ "a": 1, "b": 2 }`)); err != nil {
+		t.Fatalf("VerifySubject: %v", err)
+	}
+}
+
+func TestAttest_VerifySubject_Mismatch_Fail(t *testing.T) {
+	s, err := NewSubject("app.bin", []byte("v1"), V1PayloadEncodingRaw)
+	if err != nil {
+		t.Fatalf("NewSubject: %v", err)
+	}
+	err = VerifySubject(s, []byte("v2"))
+	if err == nil {
+		t.Fatalf("want error, got nil")
+	}
+	if !strings.Contains(err.Error(), "digest mismatch") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestAttest_TextSubject_RecordsSteps(t *testing.T) {
+	steps := DefaultTextNormalization()
+	steps.TrimTrailingWhitespace = true
+	s, err := NewTextSubject("notes.txt", []byte("a  \nb\n"), steps)
+	if err != nil {
+		t.Fatalf("NewTextSubject: %v", err)
+	}
+	if s.Annotations[SubjectAnnotationPayloadEncoding] != V1PayloadEncodingText || s.Annotations[SubjectAnnotationPayloadText] == "" {
+		t.Fatalf("unexpected subject: %+v", s)
+	}
+	if err := VerifySubject(s, []byte("a\r\nb \r\n")); err != nil {
+		t.Fatalf("VerifySubject: %v", err)
+	}
+
+	// without trimming, trailing whitespace counts
+	s, err = NewTextSubject("notes.txt", []byte("a\nb\n"), DefaultTextNormalization())
+	if err != nil {
+		t.Fatalf("NewTextSubject: %v", err)
+	}
+	if err := VerifySubject(s, []byte("a \nb\n")); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+
+	delete(s.Annotations, SubjectAnnotationPayloadText)
+	if err := VerifySubject(s, []byte("a\nb\n")); err == nil {
+		t.Fatalf("want error for missing %s, got nil", SubjectAnnotationPayloadText)
+	}
+}
+
+func TestAttest_Sign_NoSubject_Fail(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := Statement{Type: InTotoStatementTypeV1, PredicateType: SLSAProvenanceV1}
+	if _, err := SignAttestationEd25519(st, "builder-1", priv); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+}
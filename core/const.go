@@ -1,9 +1,17 @@
 package core
 
 const (
-	Version1               = 1
-	V1PayloadHashAlgSHA256 = "sha256"
-	V1AlgEd25519           = "ed25519"
-	V1PayloadEncodingJCS   = "jcs"
-	V1PayloadEncodingRaw   = "raw"
+	Version1                = 1
+	Version2                = 2
+	V1PayloadHashAlgSHA256  = "sha256"
+	V1AlgEd25519            = "ed25519"
+	V1PayloadEncodingJCS    = "jcs"
+	V1PayloadEncodingRaw    = "raw"
+	V1PayloadEncodingText   = "text"
+	V1PayloadEncodingCBOR   = "cbor"
+	V1PayloadEncodingYAML   = "yaml"
+	V1PayloadEncodingNDJSON = "ndjson"
+	V1PayloadEncodingSD     = "sd-jcs"
+	V1PayloadEncodingTar    = "tar"
+	V1PayloadEncodingZip    = "zip"
 )
@@ -0,0 +1,276 @@
+package core
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// COSE (RFC 9052) identifiers used by the COSE_Sign1 representation.
+const (
+	COSETagSign1     = 18
+	COSEAlgEdDSA     = -8
+	COSEAlgSHA256    = -16
+	coseSign1Context = "Signature1"
+)
+
+// coseSign1 is the untagged COSE_Sign1 array. The payload is the raw
+// payload_hash digest, so the message stays small and devices never need
+// the payload canonicalizer on the verify side.
+type coseSign1 struct {
+	_           struct{} `cbor:",toarray"`
+	Protected   []byte
+	Unprotected map[int64]any
+	Payload     []byte
+	Signature   []byte
+}
+
+// coseProtected is the protected header. Envelope members that have no COSE
+// equivalent (v, payload_encoding, iat, ts_*, ...) travel in "veriseal".
+type coseProtected struct {
+	Alg            int64          `cbor:"1,keyasint"`
+	Kid            []byte         `cbor:"4,keyasint"`
+	PayloadHashAlg int64          `cbor:"258,keyasint"`
+	Veriseal       map[string]any `cbor:"veriseal"`
+}
+
+var (
+	coseEncMode cbor.EncMode
+	coseDecMode cbor.DecMode
+)
+
+func init() {
+	var err error
+	coseEncMode, err = cbor.CoreDetEncOptions().EncMode()
+	if err != nil {
+		panic(err)
+	}
+	coseDecMode, err = cbor.DecOptions{
+		DupMapKey:         cbor.DupMapKeyEnforcedAPF,
+		IndefLength:       cbor.IndefLengthForbidden,
+		DefaultMapType:    reflect.TypeOf(map[string]any{}),
+		ExtraReturnErrors: cbor.ExtraDecErrorUnknownField,
+	}.DecMode()
+	if err != nil {
+		panic(err)
+	}
+}
+
+// SignCOSE computes payload_hash and signs the envelope as a tagged
+// COSE_Sign1 message over the COSE Sig_structure.
+func SignCOSE(envelope Envelope, payloadBytes []byte, priv ed25519.PrivateKey, setIat bool) ([]byte, error) {
+	return SignCOSEWithOptions(envelope, payloadBytes, priv, SignOptions{SetIat: setIat})
+}
+
+// SignCOSEWithOptions is SignCOSE with explicit options.
+func SignCOSEWithOptions(envelope Envelope, payloadBytes []byte, priv ed25519.PrivateKey, opts SignOptions) ([]byte, error) {
+	unsigned, err := prepareUnsigned(envelope, payloadBytes, opts)
+	if err != nil {
+		return nil, err
+	}
+	return encodeCOSE(unsigned, priv)
+}
+
+// ExportCOSE converts a signed JSON envelope into a COSE_Sign1 message. The
+// envelope's own signatures are carried in the protected header so that
+// ImportCOSE returns the same signed envelope. priv must be the key the
+// envelope was signed with.
+func ExportCOSE(envelope Envelope, priv ed25519.PrivateKey) ([]byte, error) {
+	if err := VerifyEd25519(envelope, priv.Public().(ed25519.PublicKey)); err != nil {
+		return nil, fmt.Errorf("envelope is not signed by this key: %w", err)
+	}
+	envelope.Payload = nil
+	return encodeCOSE(envelope, priv)
+}
+
+// VerifyCOSE verifies a COSE_Sign1 message and returns the envelope it
+// describes.
+func VerifyCOSE(b []byte, pub ed25519.PublicKey) (Envelope, error) {
+	if pub == nil {
+		return Envelope{}, fmt.Errorf("missing public key")
+	}
+	return ImportCOSE(b, pub)
+}
+
+// ImportCOSE converts a COSE_Sign1 message back into a JSON envelope. If pub
+// is non-nil the COSE signature is verified first. The envelope carries
+// "sig" only if the message was produced by ExportCOSE; a message signed
+// with SignCOSE yields an unsigned envelope.
+func ImportCOSE(b []byte, pub ed25519.PublicKey) (Envelope, error) {
+	msg, err := parseCOSESign1(b)
+	if err != nil {
+		return Envelope{}, err
+	}
+
+	var hdr coseProtected
+	if err := coseDecMode.Unmarshal(msg.Protected, &hdr); err != nil {
+		return Envelope{}, fmt.Errorf("invalid cose: protected header: %w", err)
+	}
+	if hdr.Alg != COSEAlgEdDSA {
+		return Envelope{}, fmt.Errorf("unsupported cose alg: %d", hdr.Alg)
+	}
+	if hdr.PayloadHashAlg != COSEAlgSHA256 {
+		return Envelope{}, fmt.Errorf("unsupported cose payload hash alg: %d", hdr.PayloadHashAlg)
+	}
+	if hdr.Veriseal == nil {
+		return Envelope{}, fmt.Errorf("cose carries no veriseal header")
+	}
+
+	if pub != nil {
+		toBeSigned, err := coseSigStructure(msg.Protected, msg.Payload)
+		if err != nil {
+			return Envelope{}, err
+		}
+		if !ed25519.Verify(pub, toBeSigned, msg.Signature) {
+			return Envelope{}, fmt.Errorf("cose signature verification failed")
+		}
+	}
+
+	fields := hdr.Veriseal
+	for _, k := range []string{"alg", "kid", "payload_hash_alg", "payload_hash", "payload"} {
+		if _, ok := fields[k]; ok {
+			return Envelope{}, fmt.Errorf("invalid veriseal header: unexpected field: %s", k)
+		}
+	}
+	fields["alg"] = V1AlgEd25519
+	fields["kid"] = string(hdr.Kid)
+	fields["payload_hash_alg"] = V1PayloadHashAlgSHA256
+	fields["payload_hash"] = base64.StdEncoding.EncodeToString(msg.Payload)
+
+	j, err := json.Marshal(fields)
+	if err != nil {
+		return Envelope{}, fmt.Errorf("invalid veriseal header: %w", err)
+	}
+	envelope, err := ParseEnvelopeStrict(j)
+	if err != nil {
+		return Envelope{}, fmt.Errorf("invalid veriseal header: %w", err)
+	}
+	if err := ValidateEnvelope(envelope); err != nil {
+		return Envelope{}, err
+	}
+	return envelope, nil
+}
+
+func encodeCOSE(envelope Envelope, priv ed25519.PrivateKey) ([]byte, error) {
+	if err := ValidateEnvelope(envelope); err != nil {
+		return nil, err
+	}
+	digest, err := base64.StdEncoding.DecodeString(envelope.PayloadHash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload_hash (base64 decode failed)")
+	}
+
+	fields, err := coseVerisealFields(envelope)
+	if err != nil {
+		return nil, err
+	}
+	protected, err := coseEncMode.Marshal(coseProtected{
+		Alg:            COSEAlgEdDSA,
+		Kid:            []byte(envelope.Kid),
+		PayloadHashAlg: COSEAlgSHA256,
+		Veriseal:       fields,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	toBeSigned, err := coseSigStructure(protected, digest)
+	if err != nil {
+		return nil, err
+	}
+	msg := coseSign1{
+		Protected:   protected,
+		Unprotected: map[int64]any{},
+		Payload:     digest,
+		Signature:   ed25519.Sign(priv, toBeSigned),
+	}
+	return coseEncMode.Marshal(cbor.Tag{Number: COSETagSign1, Content: msg})
+}
+
+// coseVerisealFields returns the envelope members that are not expressed by
+// standard COSE headers, with JSON numbers turned into CBOR integers.
+func coseVerisealFields(envelope Envelope) (map[string]any, error) {
+	b, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var fields map[string]any
+	if err := dec.Decode(&fields); err != nil {
+		return nil, err
+	}
+	for _, k := range []string{"alg", "kid", "payload_hash_alg", "payload_hash", "payload"} {
+		delete(fields, k)
+	}
+	v, err := jsonNumbersToInt(fields)
+	if err != nil {
+		return nil, err
+	}
+	return v.(map[string]any), nil
+}
+
+func jsonNumbersToInt(v any) (any, error) {
+	switch t := v.(type) {
+	case json.Number:
+		n, err := t.Int64()
+		if err != nil {
+			return nil, fmt.Errorf("non-integer number in envelope: %s", t)
+		}
+		return n, nil
+	case map[string]any:
+		for k, e := range t {
+			c, err := jsonNumbersToInt(e)
+			if err != nil {
+				return nil, err
+			}
+			t[k] = c
+		}
+	case []any:
+		for i, e := range t {
+			c, err := jsonNumbersToInt(e)
+			if err != nil {
+				return nil, err
+			}
+			t[i] = c
+		}
+	}
+	return v, nil
+}
+
+// parseCOSESign1 decodes a COSE_Sign1 message, tagged (18) or untagged.
+func parseCOSESign1(b []byte) (coseSign1, error) {
+	var raw cbor.RawTag
+	if err := coseDecMode.Unmarshal(b, &raw); err == nil {
+		if raw.Number != COSETagSign1 {
+			return coseSign1{}, fmt.Errorf("invalid cose: unexpected tag %d", raw.Number)
+		}
+		b = raw.Content
+	}
+
+	var msg coseSign1
+	if err := coseDecMode.Unmarshal(b, &msg); err != nil {
+		return coseSign1{}, fmt.Errorf("invalid cose: %w", err)
+	}
+	if len(msg.Protected) == 0 {
+		return coseSign1{}, fmt.Errorf("invalid cose: missing protected header")
+	}
+	if len(msg.Unprotected) != 0 {
+		return coseSign1{}, fmt.Errorf("invalid cose: unprotected header must be empty")
+	}
+	if msg.Payload == nil {
+		return coseSign1{}, fmt.Errorf("invalid cose: detached payload is not supported")
+	}
+	return msg, nil
+}
+
+// coseSigStructure builds the RFC 9052 Sig_structure for COSE_Sign1 with an
+// empty external_aad.
+func coseSigStructure(protected, payload []byte) ([]byte, error) {
+	return coseEncMode.Marshal([]any{coseSign1Context, protected, []byte{}, payload})
+}
@@ -0,0 +1,144 @@
+package core
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// -----------------------------------------------------------------------------
+// COSE_Sign1
+// -----------------------------------------------------------------------------
+
+func TestCOSE_SignVerify_OK(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := []byte("hello")
+	msg, err := SignCOSE(baseEnvelopeRaw(), payload, priv, true)
+	if err != nil {
+		t.Fatalf("SignCOSE: %v", err)
+	}
+	if msg[0] != 0xd2 {
+		t.Fatalf("want COSE_Sign1 tag (0xd2), got 0x%x", msg[0])
+	}
+
+	env, err := VerifyCOSE(msg, pub)
+	if err != nil {
+		t.Fatalf("VerifyCOSE: %v", err)
+	}
+	if env.Kid != "demo-1" || env.Iat == nil || env.Sig != nil {
+		t.Fatalf("unexpected envelope: %+v", env)
+	}
+	if err := VerifyPayloadHash(env, payload); err != nil {
+		t.Fatalf("VerifyPayloadHash: %v", err)
+	}
+}
+
+func TestCOSE_Verify_WrongKey_Fail(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := SignCOSE(baseEnvelopeRaw(), []byte("hello"), priv, false)
+	if err != nil {
+		t.Fatalf("SignCOSE: %v", err)
+	}
+	if _, err := VerifyCOSE(msg, otherPub); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+}
+
+func TestCOSE_Verify_TamperedHeader_Fail(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := SignCOSE(baseEnvelopeRaw(), []byte("hello"), priv, false)
+	if err != nil {
+		t.Fatalf("SignCOSE: %v", err)
+	}
+	// "raw" -> "jcs" inside the protected header
+	i := strings.Index(string(msg), "raw")
+	if i < 0 {
+		t.Fatal("payload_encoding not found in message")
+	}
+	tampered := append([]byte(nil), msg...)
+	copy(tampered[i:], "jcs")
+
+	if _, err := VerifyCOSE(tampered, pub); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+}
+
+func TestCOSE_ExportImport_RoundTrip(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := []byte(`{"b":2,"a":1}`)
+	signed, err := SignEd25519(baseEnvelopeJCS(), payload, priv, true)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	msg, err := ExportCOSE(signed, priv)
+	if err != nil {
+		t.Fatalf("ExportCOSE: %v", err)
+	}
+	got, err := ImportCOSE(msg, pub)
+	if err != nil {
+		t.Fatalf("ImportCOSE: %v", err)
+	}
+	if !reflect.DeepEqual(got, signed) {
+		t.Fatalf("round trip mismatch:\nwant %+v\ngot  %+v", signed, got)
+	}
+	if err := VerifyEd25519(got, pub); err != nil {
+		t.Fatalf("verify imported envelope: %v", err)
+	}
+}
+
+func TestCOSE_Export_NotSignedByKey_Fail(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed, err := SignEd25519(baseEnvelopeRaw(), []byte("hello"), priv, false)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if _, err := ExportCOSE(signed, otherPriv); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+}
+
+func TestCOSE_Import_Untagged_OK(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := SignCOSE(baseEnvelopeRaw(), []byte("hello"), priv, false)
+	if err != nil {
+		t.Fatalf("SignCOSE: %v", err)
+	}
+	if _, err := VerifyCOSE(msg[1:], pub); err != nil {
+		t.Fatalf("VerifyCOSE (untagged): %v", err)
+	}
+}
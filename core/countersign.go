@@ -0,0 +1,55 @@
+package core
+
+import (
+	"crypto/ed25519"
+	"fmt"
+)
+
+// CountersignEd25519 lets a witness attest that it saw a signed envelope at
+// a given time. The result is a new jcs envelope signed by the witness whose
+// payload is the original signed envelope JSON (including its sig); iat is
+// always set.
+func CountersignEd25519(originalJSON []byte, kid string, priv ed25519.PrivateKey) (Envelope, error) {
+	original, err := ParseEnvelopeStrict(originalJSON)
+	if err != nil {
+		return Envelope{}, fmt.Errorf("original: %w", err)
+	}
+	if err := ValidateEnvelopeV1ForVerify(original); err != nil {
+		return Envelope{}, fmt.Errorf("original: %w", err)
+	}
+
+	env, err := NewEnvelopeTemplateV1(kid, V1PayloadEncodingJCS)
+	if err != nil {
+		return Envelope{}, err
+	}
+	return SignEd25519(env, originalJSON, priv, true)
+}
+
+// VerifyCountersignedEd25519 checks both layers in one call: the original
+// signature, the witness signature, and that the countersignature's
+// payload_hash covers the original signed envelope.
+func VerifyCountersignedEd25519(originalJSON, counterJSON []byte, signerPub, witnessPub ed25519.PublicKey) error {
+	if _, err := VerifyEd25519JSON(originalJSON, signerPub); err != nil {
+		return fmt.Errorf("original: %w", err)
+	}
+	if err := VerifyCountersignatureEd25519(originalJSON, counterJSON, witnessPub); err != nil {
+		return err
+	}
+	return nil
+}
+
+// VerifyCountersignatureEd25519 checks only the witness layer: the witness
+// signature and that its payload_hash covers the original signed envelope.
+func VerifyCountersignatureEd25519(originalJSON, counterJSON []byte, witnessPub ed25519.PublicKey) error {
+	counter, err := VerifyEd25519JSON(counterJSON, witnessPub)
+	if err != nil {
+		return fmt.Errorf("countersignature: %w", err)
+	}
+	if counter.PayloadEncoding != V1PayloadEncodingJCS {
+		return fmt.Errorf("countersignature: unexpected payload_encoding: %s", counter.PayloadEncoding)
+	}
+	if err := VerifyPayloadHash(counter, originalJSON); err != nil {
+		return fmt.Errorf("countersignature: %w", err)
+	}
+	return nil
+}
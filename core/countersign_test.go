@@ -0,0 +1,100 @@
+package core
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// -----------------------------------------------------------------------------
+// V1: Countersignature
+// -----------------------------------------------------------------------------
+
+func TestV1_Countersign_VerifyBothLayers_OK(t *testing.T) {
+	originalJSON, signerPub := signedJSONForTest(t)
+	witnessPub, witnessPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counter, err := CountersignEd25519(originalJSON, "witness-1", witnessPriv)
+	if err != nil {
+		t.Fatalf("countersign: %v", err)
+	}
+	if counter.Iat == nil {
+		t.Fatalf("iat should be set")
+	}
+	counterJSON, err := json.Marshal(counter)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := VerifyCountersignedEd25519(originalJSON, counterJSON, signerPub, witnessPub); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+}
+
+func TestV1_Countersign_OriginalSigTampered_Fail(t *testing.T) {
+	originalJSON, signerPub := signedJSONForTest(t)
+	witnessPub, witnessPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counter, err := CountersignEd25519(originalJSON, "witness-1", witnessPriv)
+	if err != nil {
+		t.Fatalf("countersign: %v", err)
+	}
+	counterJSON, err := json.Marshal(counter)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Re-sign the original with another key: the envelope is still validly
+	// signed by someone, but not the one the witness saw.
+	var original Envelope
+	if err := json.Unmarshal(originalJSON, &original); err != nil {
+		t.Fatal(err)
+	}
+	_, otherPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resigned, err := SignEd25519(original, []byte(`{"a":1}`), otherPriv, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resignedJSON, err := json.Marshal(resigned)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = VerifyCountersignatureEd25519(resignedJSON, counterJSON, witnessPub)
+	if err == nil {
+		t.Fatalf("want error, got nil")
+	}
+	if !strings.Contains(err.Error(), "countersignature: payload hash mismatch") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := VerifyCountersignedEd25519(resignedJSON, counterJSON, signerPub, witnessPub); err == nil {
+		t.Fatalf("want error for re-signed original, got nil")
+	}
+}
+
+func TestV1_Countersign_UnsignedOriginal_Fail(t *testing.T) {
+	_, witnessPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(baseEnvelopeJCS())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := CountersignEd25519(b, "witness-1", witnessPriv); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+}
@@ -0,0 +1,248 @@
+package core
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/na0h/veriseal/canonical"
+)
+
+// sdSaltSize is the number of random bytes in each disclosure salt.
+const sdSaltSize = 16
+
+// SDPayload is the payload of an "sd-jcs" envelope. Every field of the
+// original JSON object (recursing into nested objects, so arrays and
+// scalars are leaves) has a salted digest in Digests, sorted, which says
+// nothing about the field but that it exists. Disclosures holds the fields
+// that are still disclosed, each with its salt and JSON pointer; a holder
+// may remove entries without invalidating the signature, which covers
+// Digests only.
+type SDPayload struct {
+	Digests     []string       `json:"digests"`
+	Disclosures []SDDisclosure `json:"disclosures"`
+}
+
+// SDDisclosure is one disclosed field. In JSON it is the array
+// [salt, pointer, value] that its digest is computed over, as in SD-JWT.
+type SDDisclosure struct {
+	Salt    string
+	Pointer string
+	Value   json.RawMessage
+}
+
+func (d SDDisclosure) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]any{d.Salt, d.Pointer, d.Value})
+}
+
+func (d *SDDisclosure) UnmarshalJSON(b []byte) error {
+	var parts []json.RawMessage
+	if err := json.Unmarshal(b, &parts); err != nil {
+		return err
+	}
+	if len(parts) != 3 {
+		return fmt.Errorf("disclosure must be [salt, pointer, value]")
+	}
+	if err := json.Unmarshal(parts[0], &d.Salt); err != nil {
+		return fmt.Errorf("disclosure salt: %w", err)
+	}
+	if err := json.Unmarshal(parts[1], &d.Pointer); err != nil {
+		return fmt.Errorf("disclosure pointer: %w", err)
+	}
+	d.Value = parts[2]
+	return nil
+}
+
+// digest is the base64 sha256 of the JCS form of [salt, pointer, value].
+func (d SDDisclosure) digest() (string, error) {
+	b, err := json.Marshal(d)
+	if err != nil {
+		return "", err
+	}
+	c, err := canonical.Canonicalize(b)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(c)
+	return base64.StdEncoding.EncodeToString(sum[:]), nil
+}
+
+// MakeSDPayload turns a JSON object into an SDPayload with a fresh random
+// salt per field and every field disclosed. The object must be I-JSON.
+func MakeSDPayload(doc []byte) ([]byte, error) {
+	if err := canonical.ValidateIJSON(doc); err != nil {
+		if errors.Is(err, canonical.ErrNotIJSON) {
+			return nil, fmt.Errorf("invalid payload: %w", err)
+		}
+		return nil, fmt.Errorf("payload_encoding=sd-jcs but payload is not valid JSON")
+	}
+	sd := SDPayload{Digests: []string{}, Disclosures: []SDDisclosure{}}
+	if err := sd.addFields("", doc); err != nil {
+		return nil, err
+	}
+	slices.Sort(sd.Digests)
+	slices.SortFunc(sd.Disclosures, func(a, b SDDisclosure) int { return strings.Compare(a.Pointer, b.Pointer) })
+	return json.Marshal(sd)
+}
+
+func (sd *SDPayload) addFields(ptr string, obj json.RawMessage) error {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(obj, &members); err != nil {
+		return fmt.Errorf("payload_encoding=sd-jcs requires a JSON object")
+	}
+	for name, v := range members {
+		child := jsonPointer(ptr, name)
+		if isNonEmptyObject(v) {
+			if err := sd.addFields(child, v); err != nil {
+				return err
+			}
+			continue
+		}
+		salt := make([]byte, sdSaltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return err
+		}
+		d := SDDisclosure{Salt: base64.StdEncoding.EncodeToString(salt), Pointer: child, Value: v}
+		digest, err := d.digest()
+		if err != nil {
+			return err
+		}
+		sd.Digests = append(sd.Digests, digest)
+		sd.Disclosures = append(sd.Disclosures, d)
+	}
+	return nil
+}
+
+func isNonEmptyObject(v json.RawMessage) bool {
+	var m map[string]json.RawMessage
+	return bytes.HasPrefix(bytes.TrimSpace(v), []byte("{")) && json.Unmarshal(v, &m) == nil && len(m) > 0
+}
+
+// parseSDPayload decodes an SDPayload strictly and checks every disclosure
+// against its digest.
+func parseSDPayload(payload []byte) (SDPayload, error) {
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.DisallowUnknownFields()
+	var sd SDPayload
+	if err := dec.Decode(&sd); err != nil {
+		return SDPayload{}, fmt.Errorf("payload_encoding=sd-jcs but payload is not a valid sd payload: %w", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return SDPayload{}, fmt.Errorf("payload_encoding=sd-jcs but payload is not a valid sd payload: trailing data")
+	}
+	if sd.Digests == nil {
+		return SDPayload{}, fmt.Errorf("payload_encoding=sd-jcs but payload has no digests")
+	}
+	digests := make(map[string]bool, len(sd.Digests))
+	for _, d := range sd.Digests {
+		if digests[d] {
+			return SDPayload{}, fmt.Errorf("payload_encoding=sd-jcs but payload repeats digest %s", d)
+		}
+		digests[d] = true
+	}
+	pointers := map[string]bool{}
+	for _, d := range sd.Disclosures {
+		if tokens, err := parsePointer(d.Pointer); err != nil || len(tokens) == 0 {
+			return SDPayload{}, fmt.Errorf("invalid disclosure pointer %q", d.Pointer)
+		}
+		if pointers[d.Pointer] {
+			return SDPayload{}, fmt.Errorf("duplicate disclosure %q", d.Pointer)
+		}
+		pointers[d.Pointer] = true
+		got, err := d.digest()
+		if err != nil {
+			return SDPayload{}, fmt.Errorf("disclosure %q: %w", d.Pointer, err)
+		}
+		if !digests[got] {
+			return SDPayload{}, fmt.Errorf("disclosure %q does not match any digest", d.Pointer)
+		}
+	}
+	return sd, nil
+}
+
+// normalizeSD returns the bytes payload_hash covers: the JCS form of the
+// sorted digest list.
+func normalizeSD(payload []byte) ([]byte, error) {
+	sd, err := parseSDPayload(payload)
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(slices.Sorted(slices.Values(sd.Digests)))
+	if err != nil {
+		return nil, err
+	}
+	return canonical.Canonicalize(b)
+}
+
+// RedactSDPayload removes the disclosures of the fields at the given JSON
+// pointers, and of every field below them. Each pointer must match at least
+// one disclosed field.
+func RedactSDPayload(payload []byte, pointers []string) ([]byte, error) {
+	sd, err := parseSDPayload(payload)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range pointers {
+		if _, err := parsePointer(p); err != nil {
+			return nil, err
+		}
+		n := len(sd.Disclosures)
+		sd.Disclosures = slices.DeleteFunc(sd.Disclosures, func(d SDDisclosure) bool {
+			return p == "" || d.Pointer == p || strings.HasPrefix(d.Pointer, p+"/")
+		})
+		if len(sd.Disclosures) == n {
+			return nil, fmt.Errorf("no disclosed field at %q", p)
+		}
+	}
+	return json.Marshal(sd)
+}
+
+// DisclosedPayload rebuilds the JSON object made of the disclosed fields and
+// counts the redacted ones; which fields those are is not recorded
+// anywhere. It checks every disclosure against the digests but not the
+// digests against payload_hash; verify the envelope first.
+func DisclosedPayload(payload []byte) ([]byte, int, error) {
+	sd, err := parseSDPayload(payload)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	doc := map[string]any{}
+	for _, d := range sd.Disclosures {
+		tokens, _ := parsePointer(d.Pointer)
+		obj := doc
+		for _, t := range tokens[:len(tokens)-1] {
+			next, ok := obj[t].(map[string]any)
+			if !ok {
+				if _, taken := obj[t]; taken {
+					return nil, 0, fmt.Errorf("conflicting disclosure %q", d.Pointer)
+				}
+				next = map[string]any{}
+				obj[t] = next
+			}
+			obj = next
+		}
+		last := tokens[len(tokens)-1]
+		if _, taken := obj[last]; taken {
+			return nil, 0, fmt.Errorf("conflicting disclosure %q", d.Pointer)
+		}
+		obj[last] = d.Value
+	}
+
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return nil, 0, err
+	}
+	out, err := canonical.Canonicalize(b)
+	if err != nil {
+		return nil, 0, err
+	}
+	return out, len(sd.Digests) - len(sd.Disclosures), nil
+}
@@ -0,0 +1,129 @@
+package core
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// -----------------------------------------------------------------------------
+// payload_encoding=sd-jcs
+// -----------------------------------------------------------------------------
+
+func signedSDForTest(t *testing.T, doc string) (Envelope, []byte, ed25519.PublicKey) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sd, err := MakeSDPayload([]byte(doc))
+	if err != nil {
+		t.Fatalf("make sd payload: %v", err)
+	}
+	env, err := NewEnvelopeTemplateV1("demo-1", V1PayloadEncodingSD)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := SignEd25519(env, sd, priv, false)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if err := VerifyEd25519(signed, pub); err != nil {
+		t.Fatalf("verify sig: %v", err)
+	}
+	return signed, sd, pub
+}
+
+func TestSD_RedactThenVerify_OK(t *testing.T) {
+	signed, sd, _ := signedSDForTest(t, `{"name":"Ann","ssn":"123","address":{"city":"Oslo","street":"Main 1"},"tags":["a"],"empty":{}}`)
+
+	var parsed SDPayload
+	if err := json.Unmarshal(sd, &parsed); err != nil {
+		t.Fatal(err)
+	}
+	var ptrs []string
+	for _, d := range parsed.Disclosures {
+		ptrs = append(ptrs, d.Pointer)
+	}
+	if want := []string{"/address/city", "/address/street", "/empty", "/name", "/ssn", "/tags"}; !reflect.DeepEqual(ptrs, want) {
+		t.Fatalf("got %v, want %v", ptrs, want)
+	}
+	if len(parsed.Digests) != len(ptrs) {
+		t.Fatalf("got %d digests, want %d", len(parsed.Digests), len(ptrs))
+	}
+
+	redacted, err := RedactSDPayload(sd, []string{"/ssn", "/address"})
+	if err != nil {
+		t.Fatalf("redact: %v", err)
+	}
+	if err := VerifyPayloadHash(signed, redacted); err != nil {
+		t.Fatalf("verify redacted payload: %v", err)
+	}
+	// the names of redacted fields are gone with their disclosures
+	for _, ptr := range []string{`"/ssn"`, `"/address/city"`, `"/address/street"`} {
+		if strings.Contains(string(redacted), ptr) {
+			t.Fatalf("redacted payload still mentions %s: %s", ptr, redacted)
+		}
+	}
+
+	disclosed, hidden, err := DisclosedPayload(redacted)
+	if err != nil {
+		t.Fatalf("disclosed: %v", err)
+	}
+	if want := `{"empty":{},"name":"Ann","tags":["a"]}`; string(disclosed) != want {
+		t.Fatalf("got %s, want %s", disclosed, want)
+	}
+	if hidden != 3 {
+		t.Fatalf("got %d redacted fields, want 3", hidden)
+	}
+}
+
+func TestSD_TamperedDisclosure_Fail(t *testing.T) {
+	signed, sd, _ := signedSDForTest(t, `{"name":"Ann","role":"user"}`)
+
+	for _, edit := range [][2]string{
+		{`"/role","user"]`, `"/role","admin"]`}, // value
+		{`"/role","user"]`, `"/admin","user"]`}, // pointer
+	} {
+		tampered := strings.Replace(string(sd), edit[0], edit[1], 1)
+		if tampered == string(sd) {
+			t.Fatal("test payload not modified")
+		}
+		if err := VerifyPayloadHash(signed, []byte(tampered)); err == nil {
+			t.Fatalf("want error, got nil")
+		}
+	}
+}
+
+func TestSD_AddedDigest_Fail(t *testing.T) {
+	signed, sd, _ := signedSDForTest(t, `{"name":"Ann"}`)
+
+	var parsed SDPayload
+	if err := json.Unmarshal(sd, &parsed); err != nil {
+		t.Fatal(err)
+	}
+	parsed.Digests = append(parsed.Digests, "AAAA")
+	b, err := json.Marshal(parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyPayloadHash(signed, b); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+}
+
+func TestSD_Redact_UnknownField_Fail(t *testing.T) {
+	_, sd, _ := signedSDForTest(t, `{"name":"Ann"}`)
+	if _, err := RedactSDPayload(sd, []string{"/nope"}); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+}
+
+func TestSD_MakePayload_NotObject_Fail(t *testing.T) {
+	if _, err := MakeSDPayload([]byte(`["a"]`)); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+}
@@ -0,0 +1,262 @@
+package core
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/na0h/veriseal/canonical"
+)
+
+const (
+	DSSEPayloadTypeJSON        = "application/json"
+	DSSEPayloadTypeOctetStream = "application/octet-stream"
+	DSSEPayloadTypeCBOR        = "application/cbor"
+	DSSEPayloadTypeYAML        = "application/yaml"
+	DSSEPayloadTypeNDJSON      = "application/x-ndjson"
+)
+
+// DSSE is a Dead Simple Signing Envelope as used by in-toto and SLSA.
+type DSSE struct {
+	PayloadType string          `json:"payloadType"`
+	Payload     string          `json:"payload"`
+	Signatures  []DSSESignature `json:"signatures"`
+}
+
+type DSSESignature struct {
+	KeyID string `json:"keyid"`
+	Sig   string `json:"sig"`
+}
+
+// DSSEPAE returns the DSSE v1 pre-authentication encoding, which is what
+// each DSSE signature covers.
+func DSSEPAE(payloadType string, payload []byte) []byte {
+	pae := fmt.Sprintf("DSSEv1 %d %s %d ", len(payloadType), payloadType, len(payload))
+	return append([]byte(pae), payload...)
+}
+
+// DSSEPayloadEncoding maps a DSSE payloadType to the payload_encoding used
+// for payload_hash: JSON media types are hashed as jcs, CBOR media types as
+// cbor, YAML media types as yaml, NDJSON / JSON Lines as ndjson, everything
+// else raw.
+func DSSEPayloadEncoding(payloadType string) string {
+	mt, _, _ := strings.Cut(payloadType, ";")
+	mt = strings.TrimSpace(strings.ToLower(mt))
+	switch {
+	case mt == DSSEPayloadTypeJSON || strings.HasSuffix(mt, "+json"):
+		return V1PayloadEncodingJCS
+	case mt == DSSEPayloadTypeCBOR || strings.HasSuffix(mt, "+cbor"):
+		return V1PayloadEncodingCBOR
+	case mt == DSSEPayloadTypeYAML || strings.HasSuffix(mt, "+yaml"):
+		return V1PayloadEncodingYAML
+	case mt == DSSEPayloadTypeNDJSON || mt == "application/jsonl":
+		return V1PayloadEncodingNDJSON
+	}
+	return V1PayloadEncodingRaw
+}
+
+// ParseDSSE decodes a DSSE JSON envelope. Input beyond
+// DefaultEnvelopeLimits is rejected first.
+func ParseDSSE(b []byte) (DSSE, error) {
+	return ParseDSSEWithLimits(b, DefaultEnvelopeLimits)
+}
+
+// ParseDSSEWithLimits is ParseDSSE with explicit limits.
+func ParseDSSEWithLimits(b []byte, l canonical.Limits) (DSSE, error) {
+	if err := canonical.CheckLimits(b, l); err != nil {
+		return DSSE{}, fmt.Errorf("invalid dsse: %w", err)
+	}
+	var d DSSE
+	if err := json.Unmarshal(b, &d); err != nil {
+		return DSSE{}, fmt.Errorf("invalid dsse: %w", err)
+	}
+	if d.PayloadType == "" {
+		return DSSE{}, fmt.Errorf("invalid dsse: missing payloadType")
+	}
+	if len(d.Signatures) == 0 {
+		return DSSE{}, fmt.Errorf("invalid dsse: missing signatures")
+	}
+	if _, err := d.DecodePayload(); err != nil {
+		return DSSE{}, err
+	}
+	return d, nil
+}
+
+// DecodePayload returns the payload bytes.
+func (d DSSE) DecodePayload() ([]byte, error) {
+	b, err := decodeDSSEBase64(d.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid dsse: payload (base64 decode failed)")
+	}
+	return b, nil
+}
+
+// ExportDSSE converts a signed envelope into a DSSE envelope whose payload is
+// the normalized payload bytes, signed with keyid = kid. An empty
+// payloadType defaults to application/json for jcs, application/cbor for
+// cbor, application/yaml for yaml, application/x-ndjson for ndjson and
+// application/octet-stream otherwise. priv must be the key the
+// envelope was signed with.
+func ExportDSSE(envelope Envelope, payload []byte, priv ed25519.PrivateKey, payloadType string) (DSSE, error) {
+	if err := VerifyEd25519(envelope, priv.Public().(ed25519.PublicKey)); err != nil {
+		return DSSE{}, fmt.Errorf("envelope is not signed by this key: %w", err)
+	}
+	norm, err := NormalizeEnvelopePayload(envelope, payload)
+	if err != nil {
+		return DSSE{}, err
+	}
+	if err := checkNormalizedPayload(envelope, norm); err != nil {
+		return DSSE{}, err
+	}
+
+	if payloadType == "" {
+		switch envelope.PayloadEncoding {
+		case V1PayloadEncodingJCS:
+			payloadType = DSSEPayloadTypeJSON
+		case V1PayloadEncodingCBOR:
+			payloadType = DSSEPayloadTypeCBOR
+		case V1PayloadEncodingYAML:
+			payloadType = DSSEPayloadTypeYAML
+		case V1PayloadEncodingNDJSON:
+			payloadType = DSSEPayloadTypeNDJSON
+		default:
+			payloadType = DSSEPayloadTypeOctetStream
+		}
+	}
+
+	sig := ed25519.Sign(priv, DSSEPAE(payloadType, norm))
+	return DSSE{
+		PayloadType: payloadType,
+		Payload:     base64.StdEncoding.EncodeToString(norm),
+		Signatures: []DSSESignature{{
+			KeyID: envelope.Kid,
+			Sig:   base64.StdEncoding.EncodeToString(sig),
+		}},
+	}, nil
+}
+
+// VerifyDSSEEd25519 succeeds if any DSSE signature verifies with pub.
+func VerifyDSSEEd25519(d DSSE, pub ed25519.PublicKey) error {
+	payload, err := d.DecodePayload()
+	if err != nil {
+		return err
+	}
+	if len(d.Signatures) == 0 {
+		return fmt.Errorf("missing sig")
+	}
+	pae := DSSEPAE(d.PayloadType, payload)
+	for _, s := range d.Signatures {
+		if verifyDSSESignature(pae, s.Sig, pub) == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("dsse signature verification failed")
+}
+
+// VerifyDSSEThresholdEd25519 verifies each DSSE signature against keys looked
+// up by keyid and requires at least threshold distinct public keys to
+// verify, as VerifyThresholdEd25519 does.
+func VerifyDSSEThresholdEd25519(d DSSE, keys map[string]ed25519.PublicKey, threshold int) ([]SignatureStatus, error) {
+	if threshold < 1 {
+		return nil, fmt.Errorf("invalid threshold: %d", threshold)
+	}
+	payload, err := d.DecodePayload()
+	if err != nil {
+		return nil, err
+	}
+	if len(d.Signatures) == 0 {
+		return nil, fmt.Errorf("missing sig")
+	}
+	pae := DSSEPAE(d.PayloadType, payload)
+
+	statuses := make([]SignatureStatus, 0, len(d.Signatures))
+	valid := signerSet{}
+	for _, s := range d.Signatures {
+		st := SignatureStatus{Kid: s.KeyID, Alg: V1AlgEd25519}
+		pub, known := keys[s.KeyID]
+		switch {
+		case s.KeyID == "":
+			st.Error = "missing keyid"
+		case !known:
+			st.Error = fmt.Sprintf("unknown kid: %s", s.KeyID)
+		default:
+			if err := verifyDSSESignature(pae, s.Sig, pub); err != nil {
+				st.Error = err.Error()
+			} else if err := valid.add(s.KeyID, pub); err != nil {
+				st.Error = err.Error()
+			} else {
+				st.OK = true
+			}
+		}
+		statuses = append(statuses, st)
+	}
+
+	if len(valid) < threshold {
+		return statuses, fmt.Errorf("threshold not met: %d of %d required signatures valid", len(valid), threshold)
+	}
+	return statuses, nil
+}
+
+// ImportDSSE converts a DSSE envelope into an unsigned v1 envelope over its
+// payload, together with the payload bytes. DSSE carries no veriseal
+// signature, so the result has to be signed again to be verified as an
+// envelope. kid defaults to the keyid of the first signature. If pub is
+// non-nil a DSSE signature must verify with it first.
+func ImportDSSE(d DSSE, kid string, pub ed25519.PublicKey) (Envelope, []byte, error) {
+	payload, err := d.DecodePayload()
+	if err != nil {
+		return Envelope{}, nil, err
+	}
+	if pub != nil {
+		if err := VerifyDSSEEd25519(d, pub); err != nil {
+			return Envelope{}, nil, err
+		}
+	}
+	if kid == "" && len(d.Signatures) > 0 {
+		kid = d.Signatures[0].KeyID
+	}
+	if kid == "" {
+		return Envelope{}, nil, fmt.Errorf("dsse signature has no keyid; set kid explicitly")
+	}
+
+	envelope := Envelope{
+		V:               Version1,
+		Alg:             V1AlgEd25519,
+		Kid:             kid,
+		PayloadEncoding: DSSEPayloadEncoding(d.PayloadType),
+		PayloadHashAlg:  V1PayloadHashAlgSHA256,
+	}
+	norm, err := NormalizeEnvelopePayload(envelope, payload)
+	if err != nil {
+		return Envelope{}, nil, err
+	}
+	size := int64(len(norm))
+	envelope.PayloadHash = hashNormalizedPayload(norm)
+	envelope.PayloadSize = &size
+	return envelope, payload, nil
+}
+
+func verifyDSSESignature(pae []byte, sigB64 string, pub ed25519.PublicKey) error {
+	sig, err := decodeDSSEBase64(sigB64)
+	if err != nil {
+		return fmt.Errorf("invalid sig (base64 decode failed)")
+	}
+	if len(sig) != ed25519.SignatureSize {
+		return fmt.Errorf("invalid sig size")
+	}
+	if !ed25519.Verify(pub, pae, sig) {
+		return fmt.Errorf("signature verification failed")
+	}
+	return nil
+}
+
+// decodeDSSEBase64 accepts standard and URL-safe base64, as the DSSE spec
+// requires of verifiers.
+func decodeDSSEBase64(s string) ([]byte, error) {
+	if b, err := base64.StdEncoding.DecodeString(s); err == nil {
+		return b, nil
+	}
+	return base64.URLEncoding.DecodeString(s)
+}
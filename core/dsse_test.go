@@ -0,0 +1,156 @@
+package core
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// -----------------------------------------------------------------------------
+// DSSE interop
+// -----------------------------------------------------------------------------
+
+func TestDSSE_PAE(t *testing.T) {
+	got := string(DSSEPAE("http://example.com/HelloWorld", []byte("hello world")))
+	want := "DSSEv1 29 http://example.com/HelloWorld 11 hello world"
+	if got != want {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+}
+
+func TestDSSE_ExportVerifyImport_OK(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := []byte(`{"b":2,"a":1}`)
+	signed, err := SignEd25519(baseEnvelopeJCS(), payload, priv, false)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	d, err := ExportDSSE(signed, payload, priv, "")
+	if err != nil {
+		t.Fatalf("ExportDSSE: %v", err)
+	}
+	if d.PayloadType != DSSEPayloadTypeJSON || d.Signatures[0].KeyID != "demo-1" {
+		t.Fatalf("unexpected dsse: %+v", d)
+	}
+
+	b, err := json.Marshal(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseDSSE(b)
+	if err != nil {
+		t.Fatalf("ParseDSSE: %v", err)
+	}
+	if err := VerifyDSSEEd25519(parsed, pub); err != nil {
+		t.Fatalf("VerifyDSSEEd25519: %v", err)
+	}
+
+	env, gotPayload, err := ImportDSSE(parsed, "", pub)
+	if err != nil {
+		t.Fatalf("ImportDSSE: %v", err)
+	}
+	if env.Sig != nil || env.Kid != "demo-1" || env.PayloadEncoding != V1PayloadEncodingJCS {
+		t.Fatalf("unexpected envelope: %+v", env)
+	}
+	if env.PayloadHash != signed.PayloadHash {
+		t.Fatalf("payload_hash: want %q, got %q", signed.PayloadHash, env.PayloadHash)
+	}
+	if string(gotPayload) != `{"a":1,"b":2}` {
+		t.Fatalf("unexpected payload: %s", gotPayload)
+	}
+}
+
+func TestDSSE_Verify_TamperedPayloadType_Fail(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := []byte("hello")
+	signed, err := SignEd25519(baseEnvelopeRaw(), payload, priv, false)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	d, err := ExportDSSE(signed, payload, priv, "application/vnd.example")
+	if err != nil {
+		t.Fatalf("ExportDSSE: %v", err)
+	}
+
+	d.PayloadType = DSSEPayloadTypeOctetStream
+	if err := VerifyDSSEEd25519(d, pub); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+}
+
+func TestDSSE_VerifyThreshold(t *testing.T) {
+	pubA, privA, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubB, privB, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := []byte("hello")
+	d := DSSE{PayloadType: DSSEPayloadTypeOctetStream, Payload: base64.StdEncoding.EncodeToString(payload)}
+	pae := DSSEPAE(d.PayloadType, payload)
+	d.Signatures = []DSSESignature{
+		{KeyID: "a", Sig: base64.StdEncoding.EncodeToString(ed25519.Sign(privA, pae))},
+		// URL-safe base64 must be accepted as well
+		{KeyID: "b", Sig: base64.URLEncoding.EncodeToString(ed25519.Sign(privB, pae))},
+	}
+	keys := map[string]ed25519.PublicKey{"a": pubA, "b": pubB}
+
+	statuses, err := VerifyDSSEThresholdEd25519(d, keys, 2)
+	if err != nil {
+		t.Fatalf("VerifyDSSEThresholdEd25519: %v", err)
+	}
+	if len(statuses) != 2 || !statuses[0].OK || !statuses[1].OK {
+		t.Fatalf("unexpected statuses: %+v", statuses)
+	}
+
+	// one key listed under two keyids is one signer
+	d.Signatures[1].Sig = base64.StdEncoding.EncodeToString(ed25519.Sign(privA, pae))
+	keys["b"] = pubA
+	statuses, err = VerifyDSSEThresholdEd25519(d, keys, 2)
+	if err == nil {
+		t.Fatalf("want error, got nil")
+	}
+	if statuses[1].OK || !strings.Contains(statuses[1].Error, "duplicate key") {
+		t.Fatalf("unexpected status: %+v", statuses[1])
+	}
+
+	delete(keys, "b")
+	if _, err := VerifyDSSEThresholdEd25519(d, keys, 2); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+}
+
+func TestDSSE_Export_NotSignedByKey_Fail(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := []byte("hello")
+	signed, err := SignEd25519(baseEnvelopeRaw(), payload, priv, false)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if _, err := ExportDSSE(signed, payload, otherPriv, ""); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+}
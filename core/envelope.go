@@ -1,5 +1,7 @@
 package core
 
+import "encoding/json"
+
 type Envelope struct {
 	V   int    `json:"v"`
 	Alg string `json:"alg"`
@@ -15,10 +17,88 @@ type Envelope struct {
 	// PayloadEncoding declares how the payload hash was computed.
 	// - "jcs": payload is JSON and the hash is computed over jcs(payload) bytes.
 	// - "raw": payload is treated as raw bytes.
+	// - "text": payload is UTF-8 text, normalized as recorded in PayloadText.
+	// - "cbor": payload is one CBOR data item, hashed in RFC 8949 core
+	//   deterministic encoding.
+	// - "yaml": payload is a YAML stream, converted to a JSON array of its
+	//   documents and hashed as jcs.
+	// - "ndjson": payload is NDJSON / JSON Lines; every non-blank line is
+	//   canonicalized with jcs and the records are joined with "\n".
+	// - "sd-jcs": payload is an SDPayload (salted digest per field); the hash
+	//   covers the digest set, so disclosed fields can be redacted.
+	// - "tar" / "zip": payload is an archive (tar optionally gzip-compressed);
+	//   the hash covers a canonical listing of its entries (see
+	//   NormalizeArchive), so timestamps, ownership and entry order do not
+	//   matter.
 	PayloadEncoding string `json:"payload_encoding"`
 
+	// PayloadText records the normalization of a "text" payload. Required
+	// for "text", not allowed otherwise.
+	PayloadText *TextNormalization `json:"payload_text,omitempty"`
+
 	PayloadHashAlg string `json:"payload_hash_alg"`
 	PayloadHash    string `json:"payload_hash,omitempty"`
 
+	// PayloadSize is the byte length of the normalized payload. Optional;
+	// set by SignEd25519 and checked before the payload is hashed.
+	PayloadSize *int64 `json:"payload_size,omitempty"`
+
+	// PayloadInclude and PayloadExclude restrict a "jcs" payload hash to
+	// part of the document: RFC 6901 JSON pointers to the values that are
+	// covered, and to values that are left out (e.g. volatile timestamps).
+	// See ProjectPayload. Optional; not allowed for other encodings.
+	PayloadInclude []string `json:"payload_include,omitempty"`
+	PayloadExclude []string `json:"payload_exclude,omitempty"`
+
+	// PayloadRecordHashes holds the sha256 of every canonical record of an
+	// "ndjson" payload, so that a mismatch can be traced to a record.
+	// Optional; not allowed for other encodings.
+	PayloadRecordHashes []string `json:"payload_record_hashes,omitempty"`
+
+	// PayloadEntryHashes holds the sha256 of every entry of a "tar" or
+	// "zip" listing by path, so that a mismatch can be traced to an entry.
+	// Optional; not allowed for other encodings.
+	PayloadEntryHashes map[string]string `json:"payload_entry_hashes,omitempty"`
+
+	// PayloadRef is a URI where the payload can be fetched (see
+	// PayloadResolver). Optional; it is signed like every other field.
+	PayloadRef string `json:"payload_ref,omitempty"`
+
 	Sig *string `json:"sig,omitempty"`
+
+	// MigratedFrom links a v2 envelope to the v1 envelope it replaces. v2 only.
+	MigratedFrom *EnvelopeLink `json:"migrated_from,omitempty"`
+
+	// Signatures holds additional signers over the same unsigned envelope
+	// (everything except "sig", "signatures" and "payload"). Optional.
+	Signatures []Signature `json:"signatures,omitempty"`
+
+	// Payload is the attached payload. Optional.
+	// - "jcs": the payload JSON itself, inline.
+	// - other encodings: a base64 string of the payload bytes.
+	// It is not part of the signed message; it is bound by payload_hash.
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// EnvelopeLink identifies another signed envelope by version and
+// OriginalEnvelopeHash.
+type EnvelopeLink struct {
+	V    int    `json:"v"`
+	Hash string `json:"hash"`
+}
+
+// Signature is one entry of Envelope.Signatures.
+type Signature struct {
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Sig string `json:"sig"`
+}
+
+// unsignedEnvelope returns the part of the envelope covered by signatures.
+func unsignedEnvelope(envelope Envelope) Envelope {
+	unsigned := envelope
+	unsigned.Sig = nil
+	unsigned.Signatures = nil
+	unsigned.Payload = nil
+	return unsigned
 }
@@ -2,6 +2,7 @@ package core
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 )
 
@@ -9,7 +10,7 @@ func NewEnvelopeTemplateV1(kid string, payloadEncoding string) (Envelope, error)
 	if strings.TrimSpace(kid) == "" {
 		return Envelope{}, fmt.Errorf("kid is required")
 	}
-	if payloadEncoding != V1PayloadEncodingJCS && payloadEncoding != V1PayloadEncodingRaw {
+	if !slices.Contains(SupportedPayloadEncodings, payloadEncoding) {
 		return Envelope{}, fmt.Errorf("invalid payloadEncoding: %s", payloadEncoding)
 	}
 
@@ -20,5 +21,18 @@ func NewEnvelopeTemplateV1(kid string, payloadEncoding string) (Envelope, error)
 		PayloadEncoding: payloadEncoding,
 		PayloadHashAlg:  V1PayloadHashAlgSHA256,
 	}
+	if payloadEncoding == V1PayloadEncodingText {
+		t := DefaultTextNormalization()
+		env.PayloadText = &t
+	}
+	return env, nil
+}
+
+func NewEnvelopeTemplateV2(kid string, payloadEncoding string) (Envelope, error) {
+	env, err := NewEnvelopeTemplateV1(kid, payloadEncoding)
+	if err != nil {
+		return Envelope{}, err
+	}
+	env.V = Version2
 	return env, nil
 }
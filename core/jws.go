@@ -0,0 +1,163 @@
+package core
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/na0h/veriseal/canonical"
+)
+
+const (
+	JWSAlgEdDSA = "EdDSA"
+	JWSType     = "veriseal+jws"
+)
+
+// JWS is an RFC 7515 JWS in flattened JSON serialization form.
+type JWS struct {
+	Protected string `json:"protected"`
+	Payload   string `json:"payload"`
+	Signature string `json:"signature"`
+}
+
+// jwsHeader is the protected header of an exported envelope. The signed
+// veriseal envelope (without an attached payload) travels in "veriseal" so
+// that importing it is lossless.
+type jwsHeader struct {
+	Alg      string          `json:"alg"`
+	Kid      string          `json:"kid"`
+	Typ      string          `json:"typ,omitempty"`
+	Veriseal json.RawMessage `json:"veriseal,omitempty"`
+}
+
+// Compact returns the JWS compact serialization.
+func (j JWS) Compact() string {
+	return j.Protected + "." + j.Payload + "." + j.Signature
+}
+
+// ParseJWS accepts the compact serialization, the flattened JSON
+// serialization, or a general JSON serialization with exactly one signature.
+func ParseJWS(b []byte) (JWS, error) {
+	if err := canonical.CheckLimits(b, DefaultEnvelopeLimits); err != nil {
+		return JWS{}, fmt.Errorf("invalid jws: %w", err)
+	}
+	s := strings.TrimSpace(string(b))
+	if !strings.HasPrefix(s, "{") {
+		parts := strings.Split(s, ".")
+		if len(parts) != 3 {
+			return JWS{}, fmt.Errorf("invalid jws: want 3 compact segments, got %d", len(parts))
+		}
+		return JWS{Protected: parts[0], Payload: parts[1], Signature: parts[2]}, nil
+	}
+
+	var general struct {
+		JWS
+		Signatures []struct {
+			Protected string `json:"protected"`
+			Signature string `json:"signature"`
+		} `json:"signatures"`
+	}
+	if err := json.Unmarshal([]byte(s), &general); err != nil {
+		return JWS{}, fmt.Errorf("invalid jws: %w", err)
+	}
+	j := general.JWS
+	if len(general.Signatures) > 0 {
+		if len(general.Signatures) != 1 {
+			return JWS{}, fmt.Errorf("invalid jws: want exactly 1 signature, got %d", len(general.Signatures))
+		}
+		j.Protected = general.Signatures[0].Protected
+		j.Signature = general.Signatures[0].Signature
+	}
+	if j.Protected == "" || j.Signature == "" {
+		return JWS{}, fmt.Errorf("invalid jws: missing protected header or signature")
+	}
+	return j, nil
+}
+
+// ExportJWS converts a signed envelope into an EdDSA JWS whose payload is the
+// normalized payload bytes, so that SHA-256 of the JWS payload is
+// payload_hash. priv must be the key the envelope was signed with.
+func ExportJWS(envelope Envelope, payload []byte, priv ed25519.PrivateKey) (JWS, error) {
+	if err := VerifyEd25519(envelope, priv.Public().(ed25519.PublicKey)); err != nil {
+		return JWS{}, fmt.Errorf("envelope is not signed by this key: %w", err)
+	}
+	norm, err := NormalizeEnvelopePayload(envelope, payload)
+	if err != nil {
+		return JWS{}, err
+	}
+	if err := checkNormalizedPayload(envelope, norm); err != nil {
+		return JWS{}, err
+	}
+
+	detached := envelope
+	detached.Payload = nil
+	vs, err := json.Marshal(detached)
+	if err != nil {
+		return JWS{}, err
+	}
+	hdr, err := json.Marshal(jwsHeader{Alg: JWSAlgEdDSA, Kid: envelope.Kid, Typ: JWSType, Veriseal: vs})
+	if err != nil {
+		return JWS{}, err
+	}
+
+	j := JWS{
+		Protected: base64.RawURLEncoding.EncodeToString(hdr),
+		Payload:   base64.RawURLEncoding.EncodeToString(norm),
+	}
+	sig := ed25519.Sign(priv, []byte(j.Protected+"."+j.Payload))
+	j.Signature = base64.RawURLEncoding.EncodeToString(sig)
+	return j, nil
+}
+
+// ImportJWS converts a JWS produced by ExportJWS back into the signed
+// envelope and the normalized payload bytes. If pub is non-nil the JWS
+// signature is verified first. The envelope's own signature is returned as
+// carried; verify it with VerifyEd25519.
+func ImportJWS(j JWS, pub ed25519.PublicKey) (Envelope, []byte, error) {
+	hdrBytes, err := base64.RawURLEncoding.DecodeString(j.Protected)
+	if err != nil {
+		return Envelope{}, nil, fmt.Errorf("invalid jws: protected header (base64url decode failed)")
+	}
+	var hdr jwsHeader
+	if err := json.Unmarshal(hdrBytes, &hdr); err != nil {
+		return Envelope{}, nil, fmt.Errorf("invalid jws: protected header: %w", err)
+	}
+	if hdr.Alg != JWSAlgEdDSA {
+		return Envelope{}, nil, fmt.Errorf("unsupported jws alg: %s", hdr.Alg)
+	}
+
+	if pub != nil {
+		sig, err := base64.RawURLEncoding.DecodeString(j.Signature)
+		if err != nil {
+			return Envelope{}, nil, fmt.Errorf("invalid jws: signature (base64url decode failed)")
+		}
+		if !ed25519.Verify(pub, []byte(j.Protected+"."+j.Payload), sig) {
+			return Envelope{}, nil, fmt.Errorf("jws signature verification failed")
+		}
+	}
+
+	if len(hdr.Veriseal) == 0 {
+		return Envelope{}, nil, fmt.Errorf("jws carries no veriseal envelope")
+	}
+	envelope, err := ParseEnvelopeStrict(hdr.Veriseal)
+	if err != nil {
+		return Envelope{}, nil, fmt.Errorf("invalid veriseal header: %w", err)
+	}
+	if err := ValidateEnvelope(envelope); err != nil {
+		return Envelope{}, nil, err
+	}
+	if hdr.Kid != envelope.Kid {
+		return Envelope{}, nil, fmt.Errorf("kid mismatch: jws %q, envelope %q", hdr.Kid, envelope.Kid)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(j.Payload)
+	if err != nil {
+		return Envelope{}, nil, fmt.Errorf("invalid jws: payload (base64url decode failed)")
+	}
+	if err := checkNormalizedPayload(envelope, payload); err != nil {
+		return Envelope{}, nil, err
+	}
+	return envelope, payload, nil
+}
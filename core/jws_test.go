@@ -0,0 +1,146 @@
+package core
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// -----------------------------------------------------------------------------
+// JWS interop
+// -----------------------------------------------------------------------------
+
+func TestJWS_ExportImport_Compact_RoundTrip(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := []byte(`{"b":2,"a":1}`)
+	signed, err := SignEd25519(baseEnvelopeJCS(), payload, priv, true)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	j, err := ExportJWS(signed, payload, priv)
+	if err != nil {
+		t.Fatalf("ExportJWS: %v", err)
+	}
+
+	// verifiable as a plain EdDSA JWS
+	parts := strings.Split(j.Compact(), ".")
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ed25519.Verify(pub, []byte(parts[0]+"."+parts[1]), sig) {
+		t.Fatalf("jws signature does not verify")
+	}
+
+	parsed, err := ParseJWS([]byte(j.Compact()))
+	if err != nil {
+		t.Fatalf("ParseJWS: %v", err)
+	}
+	got, gotPayload, err := ImportJWS(parsed, pub)
+	if err != nil {
+		t.Fatalf("ImportJWS: %v", err)
+	}
+	if !reflect.DeepEqual(got, signed) {
+		t.Fatalf("round trip mismatch:\nwant %+v\ngot  %+v", signed, got)
+	}
+	if !bytes.Equal(gotPayload, []byte(`{"a":1,"b":2}`)) {
+		t.Fatalf("unexpected payload: %s", gotPayload)
+	}
+	if err := VerifyEd25519(got, pub); err != nil {
+		t.Fatalf("verify imported envelope: %v", err)
+	}
+	if err := VerifyPayloadHash(got, gotPayload); err != nil {
+		t.Fatalf("verify payload hash: %v", err)
+	}
+}
+
+func TestJWS_ExportImport_JSON_RoundTrip(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := []byte{0x00, 0x01, 0xfe}
+	signed, err := SignEd25519(baseEnvelopeRaw(), payload, priv, false)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	j, err := ExportJWS(signed, payload, priv)
+	if err != nil {
+		t.Fatalf("ExportJWS: %v", err)
+	}
+	b, err := json.Marshal(j)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := ParseJWS(b)
+	if err != nil {
+		t.Fatalf("ParseJWS: %v", err)
+	}
+	got, gotPayload, err := ImportJWS(parsed, pub)
+	if err != nil {
+		t.Fatalf("ImportJWS: %v", err)
+	}
+	if !reflect.DeepEqual(got, signed) {
+		t.Fatalf("round trip mismatch")
+	}
+	if !bytes.Equal(gotPayload, payload) {
+		t.Fatalf("want %x, got %x", payload, gotPayload)
+	}
+}
+
+func TestJWS_Export_WrongKey_Fail(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, other, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := []byte(`{"a":1}`)
+	signed, err := SignEd25519(baseEnvelopeJCS(), payload, priv, false)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if _, err := ExportJWS(signed, payload, other); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+}
+
+func TestJWS_Import_PayloadTampered_Fail(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := []byte(`{"a":1}`)
+	signed, err := SignEd25519(baseEnvelopeJCS(), payload, priv, false)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	j, err := ExportJWS(signed, payload, priv)
+	if err != nil {
+		t.Fatalf("ExportJWS: %v", err)
+	}
+	j.Payload = base64.RawURLEncoding.EncodeToString([]byte(`{"a":2}`))
+
+	if _, _, err := ImportJWS(j, pub); err == nil {
+		t.Fatalf("want jws signature error, got nil")
+	}
+	if _, _, err := ImportJWS(j, nil); err == nil {
+		t.Fatalf("want payload hash mismatch, got nil")
+	}
+}
@@ -0,0 +1,295 @@
+package core
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/na0h/veriseal/canonical"
+)
+
+// ManifestTypeV1 identifies a directory manifest payload.
+const ManifestTypeV1 = "https://github.com/na0h/veriseal/dir-manifest/v1"
+
+const (
+	FileAdded    = "added"
+	FileRemoved  = "removed"
+	FileModified = "modified"
+	// FileSkipped is a signed file left out by the verifier's own ignore
+	// patterns: it was not checked.
+	FileSkipped = "skipped"
+)
+
+// ManifestEnvelopeLimits are the limits to parse manifest envelopes with.
+// A manifest has one member per file, so DefaultEnvelopeLimits (10000
+// members per object) would cap the directory size; members and size follow
+// canonical.DefaultLimits instead, which the attached manifest has to meet
+// to be signed and verified at all. SignManifestEd25519 refuses envelopes
+// beyond them.
+var ManifestEnvelopeLimits = canonical.Limits{
+	MaxDepth:        DefaultEnvelopeLimits.MaxDepth,
+	MaxSize:         canonical.DefaultLimits.MaxSize,
+	MaxKeys:         canonical.DefaultLimits.MaxKeys,
+	MaxStringLength: DefaultEnvelopeLimits.MaxStringLength,
+}
+
+// Manifest describes a directory tree: every regular file below the root by
+// its slash-separated relative path. Ignore holds the patterns that were
+// applied when it was built; they are signed, and applied again when the
+// tree is verified. Empty directories are not recorded.
+type Manifest struct {
+	Type   string                  `json:"_type"`
+	Ignore []string                `json:"ignore,omitempty"`
+	Files  map[string]ManifestFile `json:"files"`
+}
+
+// ManifestFile is one file of a Manifest. Mode holds the permission bits in
+// octal (e.g. "0644"); SHA256 is the hex digest of the file bytes.
+type ManifestFile struct {
+	Size   int64  `json:"size"`
+	Mode   string `json:"mode"`
+	SHA256 string `json:"sha256"`
+}
+
+// FileChange is one file that differs between a signed Manifest and the
+// tree being verified. Fields lists what changed for modified files.
+type FileChange struct {
+	Path   string   `json:"path"`
+	Change string   `json:"change"`
+	Fields []string `json:"fields,omitempty"`
+}
+
+// BuildManifest walks fsys and describes every regular file that no ignore
+// pattern matches. A pattern is a path.Match pattern matched against the
+// whole relative path and against each of its elements, so "*.tmp",
+// "node_modules" and "drafts/*.md" all work; an ignored directory is
+// skipped with everything below it. Symlinks and other special files are
+// rejected rather than silently left out, as are names that are not UTF-8.
+func BuildManifest(fsys fs.FS, ignore []string) (Manifest, error) {
+	if err := validateIgnore(ignore); err != nil {
+		return Manifest{}, err
+	}
+	m := Manifest{Type: ManifestTypeV1, Ignore: ignore, Files: map[string]ManifestFile{}}
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == "." {
+			return nil
+		}
+		if ignored(ignore, p) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if !utf8.ValidString(p) {
+			return fmt.Errorf("%q: file name is not valid UTF-8", p)
+		}
+		if !d.Type().IsRegular() {
+			return fmt.Errorf("%s: not a regular file (symlinks and special files are not supported)", p)
+		}
+		f, err := manifestFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		m.Files[p] = f
+		return nil
+	})
+	if err != nil {
+		return Manifest{}, err
+	}
+	return m, nil
+}
+
+func manifestFile(fsys fs.FS, p string) (ManifestFile, error) {
+	f, err := fsys.Open(p)
+	if err != nil {
+		return ManifestFile{}, err
+	}
+	defer f.Close() //nolint:errcheck
+	info, err := f.Stat()
+	if err != nil {
+		return ManifestFile{}, err
+	}
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return ManifestFile{}, err
+	}
+	return ManifestFile{
+		Size:   n,
+		Mode:   fmt.Sprintf("%04o", info.Mode().Perm()),
+		SHA256: hex.EncodeToString(h.Sum(nil)),
+	}, nil
+}
+
+func validateIgnore(patterns []string) error {
+	for _, p := range patterns {
+		if p == "" {
+			return fmt.Errorf("empty ignore pattern")
+		}
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid ignore pattern %q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// ignored reports whether a pattern matches the relative path p or one of
+// its elements.
+func ignored(patterns []string, p string) bool {
+	for _, pat := range patterns {
+		if ok, _ := path.Match(pat, p); ok {
+			return true
+		}
+		for _, elem := range strings.Split(p, "/") {
+			if ok, _ := path.Match(pat, elem); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ValidateManifest checks the fields every Manifest needs.
+func ValidateManifest(m Manifest) error {
+	if m.Type != ManifestTypeV1 {
+		return fmt.Errorf("unsupported manifest _type: %s", m.Type)
+	}
+	if m.Files == nil {
+		return fmt.Errorf("manifest has no files")
+	}
+	if err := validateIgnore(m.Ignore); err != nil {
+		return err
+	}
+	for p, f := range m.Files {
+		if !fs.ValidPath(p) || p == "." {
+			return fmt.Errorf("invalid manifest path: %q", p)
+		}
+		if len(f.SHA256) != hex.EncodedLen(sha256.Size) {
+			return fmt.Errorf("%s: invalid sha256", p)
+		}
+	}
+	return nil
+}
+
+// SignManifestEd25519 signs the Manifest as the attached jcs payload of a
+// new envelope; iat is always set. Parse the envelope with
+// ManifestEnvelopeLimits to verify it.
+func SignManifestEd25519(m Manifest, kid string, priv ed25519.PrivateKey) (Envelope, error) {
+	if err := ValidateManifest(m); err != nil {
+		return Envelope{}, err
+	}
+	b, err := json.Marshal(m)
+	if err == nil {
+		// canonical, so that it is attached as inline JSON
+		b, err = canonical.Canonicalize(b)
+	}
+	if err != nil {
+		return Envelope{}, err
+	}
+
+	env, err := NewEnvelopeTemplateV1(kid, V1PayloadEncodingJCS)
+	if err != nil {
+		return Envelope{}, err
+	}
+	signed, err := SignEd25519(env, b, priv, true)
+	if err == nil {
+		signed, err = AttachPayload(signed, b)
+	}
+	if err != nil {
+		return Envelope{}, err
+	}
+	if b, err = json.Marshal(signed); err != nil {
+		return Envelope{}, err
+	}
+	if err := canonical.CheckLimits(b, ManifestEnvelopeLimits); err != nil {
+		return Envelope{}, fmt.Errorf("manifest envelope: %w", err)
+	}
+	return signed, nil
+}
+
+// EnvelopeManifest returns the Manifest attached to a manifest envelope. It
+// does not verify the envelope signature.
+func EnvelopeManifest(envelope Envelope) (Manifest, error) {
+	if envelope.PayloadEncoding != V1PayloadEncodingJCS {
+		return Manifest{}, fmt.Errorf("manifest: unexpected payload_encoding: %s", envelope.PayloadEncoding)
+	}
+	b, err := AttachedPayload(envelope)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("manifest: %w", err)
+	}
+	if err := VerifyPayloadHash(envelope, b); err != nil {
+		return Manifest{}, fmt.Errorf("manifest: %w", err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return Manifest{}, fmt.Errorf("manifest: %w", err)
+	}
+	if err := ValidateManifest(m); err != nil {
+		return Manifest{}, fmt.Errorf("manifest: %w", err)
+	}
+	return m, nil
+}
+
+// VerifyManifest compares the tree in fsys with a signed Manifest and
+// returns the files that were added, removed or modified, sorted by path.
+// The manifest's own ignore patterns apply, plus the extra ones given here;
+// signed files that only the extra patterns match are not checked, and are
+// returned as FileSkipped so that they cannot go unnoticed.
+func VerifyManifest(m Manifest, fsys fs.FS, ignore []string) ([]FileChange, error) {
+	if err := validateIgnore(ignore); err != nil {
+		return nil, err
+	}
+	local, err := BuildManifest(fsys, append(slices.Clone(m.Ignore), ignore...))
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []FileChange
+	for _, p := range slices.Sorted(maps.Keys(m.Files)) {
+		if ignored(ignore, p) {
+			changes = append(changes, FileChange{Path: p, Change: FileSkipped})
+			continue
+		}
+		want := m.Files[p]
+		got, ok := local.Files[p]
+		if !ok {
+			changes = append(changes, FileChange{Path: p, Change: FileRemoved})
+			continue
+		}
+		var fields []string
+		if got.Size != want.Size {
+			fields = append(fields, "size")
+		}
+		if got.Mode != want.Mode {
+			fields = append(fields, "mode")
+		}
+		if got.SHA256 != want.SHA256 {
+			fields = append(fields, "sha256")
+		}
+		if len(fields) > 0 {
+			changes = append(changes, FileChange{Path: p, Change: FileModified, Fields: fields})
+		}
+	}
+	for p := range local.Files {
+		if _, ok := m.Files[p]; !ok {
+			changes = append(changes, FileChange{Path: p, Change: FileAdded})
+		}
+	}
+	slices.SortStableFunc(changes, func(a, b FileChange) int { return strings.Compare(a.Path, b.Path) })
+	return changes, nil
+}
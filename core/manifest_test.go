@@ -0,0 +1,218 @@
+package core
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"testing"
+	"testing/fstest"
+
+	"github.com/na0h/veriseal/canonical"
+)
+
+// -----------------------------------------------------------------------------
+// Directory manifest
+// -----------------------------------------------------------------------------
+
+func siteForTest() fstest.MapFS {
+	return fstest.MapFS{
+		"index.html":              {Data: []byte("<h1>hi</h1>"), Mode: 0o644},
+		"assets/app.js":           {Data: []byte("console.log(1)"), Mode: 0o644},
+		"assets/app.js.map":       {Data: []byte("{}"), Mode: 0o644},
+		"bin/run.sh":              {Data: []byte("#!/bin/sh"), Mode: 0o755},
+		"node_modules/x/index.js": {Data: []byte("x"), Mode: 0o644},
+	}
+}
+
+func TestManifest_SignAndVerify_OK(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	site := siteForTest()
+	m, err := BuildManifest(site, []string{"node_modules", "*.map"})
+	if err != nil {
+		t.Fatalf("BuildManifest: %v", err)
+	}
+	if want := []string{"assets/app.js", "bin/run.sh", "index.html"}; !reflect.DeepEqual(slices.Sorted(maps.Keys(m.Files)), want) {
+		t.Fatalf("got files %v, want %v", slices.Sorted(maps.Keys(m.Files)), want)
+	}
+	if f := m.Files["bin/run.sh"]; f.Mode != "0755" || f.Size != 9 {
+		t.Fatalf("unexpected entry: %+v", f)
+	}
+
+	env, err := SignManifestEd25519(m, "demo-1", priv)
+	if err != nil {
+		t.Fatalf("SignManifestEd25519: %v", err)
+	}
+	b, err := json.Marshal(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verified, err := VerifyEd25519JSON(b, pub)
+	if err != nil {
+		t.Fatalf("VerifyEd25519JSON: %v", err)
+	}
+	got, err := EnvelopeManifest(verified)
+	if err != nil {
+		t.Fatalf("EnvelopeManifest: %v", err)
+	}
+
+	// the signed ignore patterns apply again: the .map file may change
+	site["assets/app.js.map"] = &fstest.MapFile{Data: []byte(`{"v":3}`)}
+	changes, err := VerifyManifest(got, site, nil)
+	if err != nil {
+		t.Fatalf("VerifyManifest: %v", err)
+	}
+	if len(changes) != 0 {
+		t.Fatalf("want no changes, got %+v", changes)
+	}
+}
+
+func TestManifest_Verify_Changes(t *testing.T) {
+	m, err := BuildManifest(siteForTest(), []string{"node_modules"})
+	if err != nil {
+		t.Fatalf("BuildManifest: %v", err)
+	}
+
+	site := siteForTest()
+	delete(site, "index.html")
+	site["assets/app.js"] = &fstest.MapFile{Data: []byte("console.log(2)"), Mode: 0o644}
+	site["bin/run.sh"] = &fstest.MapFile{Data: []byte("#!/bin/sh"), Mode: 0o644}
+	site["assets/new.css"] = &fstest.MapFile{Data: []byte("a{}"), Mode: 0o644}
+	site["tmp/cache.bin"] = &fstest.MapFile{Data: []byte("?"), Mode: 0o644}
+
+	changes, err := VerifyManifest(m, site, []string{"tmp"})
+	if err != nil {
+		t.Fatalf("VerifyManifest: %v", err)
+	}
+	want := []FileChange{
+		{Path: "assets/app.js", Change: FileModified, Fields: []string{"sha256"}},
+		{Path: "assets/new.css", Change: FileAdded},
+		{Path: "bin/run.sh", Change: FileModified, Fields: []string{"mode"}},
+		{Path: "index.html", Change: FileRemoved},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Fatalf("got  %+v\nwant %+v", changes, want)
+	}
+}
+
+func TestManifest_Verify_ExtraIgnore_ReportsSkipped(t *testing.T) {
+	m, err := BuildManifest(siteForTest(), []string{"node_modules"})
+	if err != nil {
+		t.Fatalf("BuildManifest: %v", err)
+	}
+
+	// "*" hides every signed file: none of them may pass unnoticed
+	changes, err := VerifyManifest(m, fstest.MapFS{}, []string{"*"})
+	if err != nil {
+		t.Fatalf("VerifyManifest: %v", err)
+	}
+	if len(changes) != len(m.Files) {
+		t.Fatalf("got %d changes, want %d", len(changes), len(m.Files))
+	}
+	for _, c := range changes {
+		if c.Change != FileSkipped {
+			t.Fatalf("got %+v, want %s", c, FileSkipped)
+		}
+	}
+}
+
+// A manifest has one member per file: more files than DefaultEnvelopeLimits
+// allows members must still verify.
+func TestManifest_ManyFiles_RoundTrip(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	site := fstest.MapFS{}
+	for i := 0; i <= DefaultEnvelopeLimits.MaxKeys; i++ {
+		site[fmt.Sprintf("files/%05d.txt", i)] = &fstest.MapFile{Data: []byte(strconv.Itoa(i)), Mode: 0o644}
+	}
+	m, err := BuildManifest(site, nil)
+	if err != nil {
+		t.Fatalf("BuildManifest: %v", err)
+	}
+	env, err := SignManifestEd25519(m, "demo-1", priv)
+	if err != nil {
+		t.Fatalf("SignManifestEd25519: %v", err)
+	}
+	b, err := json.Marshal(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyEd25519JSON(b, pub); !errors.Is(err, canonical.ErrTooManyKeys) {
+		t.Fatalf("want ErrTooManyKeys with DefaultEnvelopeLimits, got %v", err)
+	}
+	verified, err := VerifyEd25519JSONWithLimits(b, pub, ManifestEnvelopeLimits)
+	if err != nil {
+		t.Fatalf("VerifyEd25519JSONWithLimits: %v", err)
+	}
+	got, err := EnvelopeManifest(verified)
+	if err != nil {
+		t.Fatalf("EnvelopeManifest: %v", err)
+	}
+	changes, err := VerifyManifest(got, site, nil)
+	if err != nil {
+		t.Fatalf("VerifyManifest: %v", err)
+	}
+	if len(got.Files) != len(site) || len(changes) != 0 {
+		t.Fatalf("got %d files, changes %v", len(got.Files), changes)
+	}
+}
+
+func TestManifest_Sign_BeyondManifestLimits_Fail(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := ManifestEnvelopeLimits
+	ManifestEnvelopeLimits.MaxKeys = 2
+	t.Cleanup(func() { ManifestEnvelopeLimits = saved })
+
+	m, err := BuildManifest(siteForTest(), nil)
+	if err != nil {
+		t.Fatalf("BuildManifest: %v", err)
+	}
+	if _, err := SignManifestEd25519(m, "demo-1", priv); !errors.Is(err, canonical.ErrTooManyKeys) {
+		t.Fatalf("want ErrTooManyKeys, got %v", err)
+	}
+}
+
+func TestManifest_Build_Fail(t *testing.T) {
+	if _, err := BuildManifest(siteForTest(), []string{"[a"}); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+	site := fstest.MapFS{"link": {Data: []byte("target"), Mode: fs.ModeSymlink}}
+	if _, err := BuildManifest(site, nil); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+}
+
+func TestManifest_Envelope_NotManifest_Fail(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := []byte(`{"files":{}}`)
+	signed, err := SignEd25519(baseEnvelopeJCS(), payload, priv, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	attached, err := AttachPayload(signed, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := EnvelopeManifest(attached); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+}
@@ -0,0 +1,129 @@
+package core
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/na0h/veriseal/canonical"
+)
+
+const (
+	// MigrateModeResign copies the v1 fields into a v2 envelope (same
+	// payload_hash) and signs it again.
+	MigrateModeResign = "resign"
+	// MigrateModeWrap creates a v2 jcs envelope whose payload is the v1
+	// signed envelope.
+	MigrateModeWrap = "wrap"
+)
+
+// OriginalEnvelopeHash identifies a signed envelope for migrated_from: the
+// base64 SHA-256 of the JCS form of the received envelope object, including
+// its signatures but without an attached payload.
+func OriginalEnvelopeHash(envelopeJSON []byte) (string, error) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(envelopeJSON, &obj); err != nil {
+		return "", err
+	}
+	delete(obj, "payload")
+
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+	msg, err := canonical.Canonicalize(b)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(msg)
+	return base64.StdEncoding.EncodeToString(sum[:]), nil
+}
+
+// MigrateV1ToV2Ed25519 turns a signed v1 envelope into a v2 envelope signed
+// by kid, with migrated_from pointing at the original. The original
+// signature is not checked here; verify it before migrating.
+func MigrateV1ToV2Ed25519(originalJSON []byte, mode string, kid string, priv ed25519.PrivateKey) (Envelope, error) {
+	original, err := ParseEnvelopeStrict(originalJSON)
+	if err != nil {
+		return Envelope{}, fmt.Errorf("original: %w", err)
+	}
+	if err := ValidateEnvelopeV1(original); err != nil {
+		return Envelope{}, fmt.Errorf("original: %w", err)
+	}
+	if err := ValidateEnvelopeV1ForVerify(original); err != nil {
+		return Envelope{}, fmt.Errorf("original: %w", err)
+	}
+	if kid == "" {
+		return Envelope{}, fmt.Errorf("kid is required")
+	}
+
+	h, err := OriginalEnvelopeHash(originalJSON)
+	if err != nil {
+		return Envelope{}, err
+	}
+	link := &EnvelopeLink{V: Version1, Hash: h}
+
+	switch mode {
+	case MigrateModeResign:
+		unsigned := unsignedEnvelope(original)
+		unsigned.V = Version2
+		unsigned.Kid = kid
+		unsigned.MigratedFrom = link
+		if unsigned.Iat == nil {
+			iat := nowUnix()
+			unsigned.Iat = &iat
+		}
+		if err := ValidateEnvelopeV2(unsigned); err != nil {
+			return Envelope{}, err
+		}
+
+		signed, err := signUnsignedEd25519(unsigned, priv)
+		if err != nil {
+			return Envelope{}, err
+		}
+		signed.Payload = original.Payload
+		return signed, nil
+	case MigrateModeWrap:
+		env, err := NewEnvelopeTemplateV2(kid, V1PayloadEncodingJCS)
+		if err != nil {
+			return Envelope{}, err
+		}
+		env.MigratedFrom = link
+		return SignEd25519(env, originalJSON, priv, true)
+	default:
+		return Envelope{}, fmt.Errorf("invalid migrate mode: %s", mode)
+	}
+}
+
+// VerifyMigrationLink checks that a migrated v2 envelope points at the given
+// original v1 envelope and covers either its payload (resign) or the
+// original envelope itself (wrap). The original's signature must verify
+// with originalPub, the key of its v1 signer. It does not verify the
+// migrated envelope's own signature.
+func VerifyMigrationLink(migrated Envelope, originalJSON []byte, originalPub ed25519.PublicKey) error {
+	if migrated.MigratedFrom == nil {
+		return fmt.Errorf("missing migrated_from")
+	}
+	h, err := OriginalEnvelopeHash(originalJSON)
+	if err != nil {
+		return err
+	}
+	if migrated.MigratedFrom.Hash != h {
+		return fmt.Errorf("migrated_from hash mismatch")
+	}
+
+	original, err := VerifyEd25519JSON(originalJSON, originalPub)
+	if err != nil {
+		return fmt.Errorf("original envelope: %w", err)
+	}
+	if migrated.PayloadEncoding == original.PayloadEncoding && migrated.PayloadHash == original.PayloadHash {
+		return nil
+	}
+	if err := VerifyPayloadHash(migrated, originalJSON); err != nil {
+		return fmt.Errorf("migrated envelope covers neither the original payload nor the original envelope")
+	}
+	return nil
+}
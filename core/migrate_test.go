@@ -0,0 +1,150 @@
+package core
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// -----------------------------------------------------------------------------
+// V2: Validation / Migration
+// -----------------------------------------------------------------------------
+
+func TestV2_SignVerify_SetsIat_OK(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env, err := NewEnvelopeTemplateV2("demo-2", V1PayloadEncodingJCS)
+	if err != nil {
+		t.Fatalf("NewEnvelopeTemplateV2: %v", err)
+	}
+
+	payload := []byte(`{"a":1}`)
+	signed, err := SignEd25519(env, payload, priv, false)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if signed.V != Version2 {
+		t.Fatalf("want v=%d, got %d", Version2, signed.V)
+	}
+	if signed.Iat == nil {
+		t.Fatalf("v2 signing should set iat")
+	}
+	if err := VerifyEd25519(signed, pub); err != nil {
+		t.Fatalf("verify sig: %v", err)
+	}
+	if err := VerifyPayloadHash(signed, payload); err != nil {
+		t.Fatalf("verify payload hash: %v", err)
+	}
+}
+
+func TestV1_Validate_MigratedFromRejected(t *testing.T) {
+	env := baseEnvelopeJCS()
+	env.MigratedFrom = &EnvelopeLink{V: Version1, Hash: "x"}
+
+	if err := ValidateEnvelope(env); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+}
+
+func TestV2_Migrate_Resign_OK(t *testing.T) {
+	originalJSON, oldPub := signedJSONForTest(t)
+	newPub, newPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	migrated, err := MigrateV1ToV2Ed25519(originalJSON, MigrateModeResign, "demo-2", newPriv)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if migrated.V != Version2 || migrated.Kid != "demo-2" {
+		t.Fatalf("unexpected migrated envelope: %+v", migrated)
+	}
+	if err := VerifyEd25519(migrated, newPub); err != nil {
+		t.Fatalf("verify sig: %v", err)
+	}
+	if err := VerifyEd25519(migrated, oldPub); err == nil {
+		t.Fatalf("want failure with the old key, got nil")
+	}
+	// payload_hash is carried over, so the original payload still verifies
+	if err := VerifyPayloadHash(migrated, []byte(`{"a":1}`)); err != nil {
+		t.Fatalf("verify payload hash: %v", err)
+	}
+	if err := VerifyMigrationLink(migrated, originalJSON, oldPub); err != nil {
+		t.Fatalf("VerifyMigrationLink: %v", err)
+	}
+	// the original must verify with the key of its v1 signer
+	if err := VerifyMigrationLink(migrated, originalJSON, newPub); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+}
+
+func TestV2_Migrate_Wrap_OK(t *testing.T) {
+	originalJSON, oldPub := signedJSONForTest(t)
+	newPub, newPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	migrated, err := MigrateV1ToV2Ed25519(originalJSON, MigrateModeWrap, "demo-2", newPriv)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if err := VerifyEd25519(migrated, newPub); err != nil {
+		t.Fatalf("verify sig: %v", err)
+	}
+	if err := VerifyPayloadHash(migrated, originalJSON); err != nil {
+		t.Fatalf("verify payload hash: %v", err)
+	}
+	if err := VerifyMigrationLink(migrated, originalJSON, oldPub); err != nil {
+		t.Fatalf("VerifyMigrationLink: %v", err)
+	}
+}
+
+func TestV2_VerifyMigrationLink_OtherOriginal_Fail(t *testing.T) {
+	originalJSON, oldPub := signedJSONForTest(t)
+	otherJSON, _ := signedJSONForTest(t)
+	_, newPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	migrated, err := MigrateV1ToV2Ed25519(originalJSON, MigrateModeResign, "demo-2", newPriv)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	err = VerifyMigrationLink(migrated, otherJSON, oldPub)
+	if err == nil {
+		t.Fatalf("want error, got nil")
+	}
+	if !strings.Contains(err.Error(), "migrated_from hash mismatch") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestV2_Migrate_RejectsV2Input(t *testing.T) {
+	originalJSON, _ := signedJSONForTest(t)
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	migrated, err := MigrateV1ToV2Ed25519(originalJSON, MigrateModeResign, "demo-2", priv)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	b, err := json.Marshal(migrated)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := MigrateV1ToV2Ed25519(b, MigrateModeResign, "demo-3", priv); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+}
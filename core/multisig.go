@@ -0,0 +1,164 @@
+package core
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/na0h/veriseal/canonical"
+)
+
+// SignatureStatus reports the verification result of one signer.
+type SignatureStatus struct {
+	Kid   string `json:"kid"`
+	Alg   string `json:"alg"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// AppendSignatureEd25519 adds a signer to an already signed envelope.
+// The new signature covers the same unsigned envelope as the primary "sig",
+// so existing signatures stay valid.
+func AppendSignatureEd25519(envelope Envelope, kid string, priv ed25519.PrivateKey) (Envelope, error) {
+	if err := ValidateEnvelope(envelope); err != nil {
+		return Envelope{}, err
+	}
+	if envelope.PayloadHash == "" {
+		return Envelope{}, fmt.Errorf("missing payload_hash")
+	}
+	if kid == "" {
+		return Envelope{}, fmt.Errorf("kid is required")
+	}
+	for _, s := range allSignatures(envelope) {
+		if s.Kid == kid {
+			return Envelope{}, fmt.Errorf("kid already signed: %s", kid)
+		}
+	}
+
+	b, err := json.Marshal(unsignedEnvelope(envelope))
+	if err != nil {
+		return Envelope{}, err
+	}
+	msg, err := canonical.Canonicalize(b)
+	if err != nil {
+		return Envelope{}, err
+	}
+
+	sig := ed25519.Sign(priv, msg)
+
+	out := envelope
+	out.Signatures = append(append([]Signature(nil), envelope.Signatures...), Signature{
+		Kid: kid,
+		Alg: V1AlgEd25519,
+		Sig: base64.StdEncoding.EncodeToString(sig),
+	})
+	return out, nil
+}
+
+// VerifyThresholdEd25519 verifies every signature on the envelope (the
+// primary "sig" and each "signatures" entry) against keys looked up by kid,
+// and requires at least threshold distinct public keys to verify: two kids
+// mapped to the same key count as one signer.
+func VerifyThresholdEd25519(envelope Envelope, keys map[string]ed25519.PublicKey, threshold int) ([]SignatureStatus, error) {
+	if err := ValidateEnvelope(envelope); err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(unsignedEnvelope(envelope))
+	if err != nil {
+		return nil, err
+	}
+	return verifyThreshold(envelope, b, keys, threshold)
+}
+
+// VerifyThresholdEd25519JSON is VerifyThresholdEd25519 over the received
+// JSON object, parsed with ParseEnvelopeStrict.
+func VerifyThresholdEd25519JSON(envelopeJSON []byte, keys map[string]ed25519.PublicKey, threshold int) ([]SignatureStatus, error) {
+	return VerifyThresholdEd25519JSONWithLimits(envelopeJSON, keys, threshold, DefaultEnvelopeLimits)
+}
+
+// VerifyThresholdEd25519JSONWithLimits is VerifyThresholdEd25519JSON with
+// explicit limits for ParseEnvelopeStrictWithLimits.
+func VerifyThresholdEd25519JSONWithLimits(envelopeJSON []byte, keys map[string]ed25519.PublicKey, threshold int, l canonical.Limits) ([]SignatureStatus, error) {
+	envelope, err := ParseEnvelopeStrictWithLimits(envelopeJSON, l)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateEnvelope(envelope); err != nil {
+		return nil, err
+	}
+	b, err := unsignedJSONFromReceived(envelopeJSON)
+	if err != nil {
+		return nil, err
+	}
+	return verifyThreshold(envelope, b, keys, threshold)
+}
+
+func verifyThreshold(envelope Envelope, unsignedJSON []byte, keys map[string]ed25519.PublicKey, threshold int) ([]SignatureStatus, error) {
+	if threshold < 1 {
+		return nil, fmt.Errorf("invalid threshold: %d", threshold)
+	}
+	if envelope.PayloadHash == "" {
+		return nil, fmt.Errorf("missing payload_hash")
+	}
+
+	sigs := allSignatures(envelope)
+	if len(sigs) == 0 {
+		return nil, fmt.Errorf("missing sig")
+	}
+
+	statuses := make([]SignatureStatus, 0, len(sigs))
+	valid := signerSet{}
+	for _, s := range sigs {
+		st := SignatureStatus{Kid: s.Kid, Alg: s.Alg}
+		pub, known := keys[s.Kid]
+		switch {
+		case s.Kid == "":
+			st.Error = "missing kid"
+		case s.Alg != V1AlgEd25519:
+			st.Error = fmt.Sprintf("unsupported alg: %s", s.Alg)
+		case !known:
+			st.Error = fmt.Sprintf("unknown kid: %s", s.Kid)
+		default:
+			if err := verifyEd25519Message(unsignedJSON, s.Sig, pub); err != nil {
+				st.Error = err.Error()
+			} else if err := valid.add(s.Kid, pub); err != nil {
+				st.Error = err.Error()
+			} else {
+				st.OK = true
+			}
+		}
+		statuses = append(statuses, st)
+	}
+
+	if len(valid) < threshold {
+		return statuses, fmt.Errorf("threshold not met: %d of %d required signatures valid", len(valid), threshold)
+	}
+	return statuses, nil
+}
+
+// signerSet collects the signers whose signature verified, by public key,
+// so that one key listed under several kids counts once towards a
+// threshold.
+type signerSet map[string]string // public key -> kid
+
+func (v signerSet) add(kid string, pub ed25519.PublicKey) error {
+	if other, dup := v[string(pub)]; dup {
+		if other == kid {
+			return fmt.Errorf("duplicate kid: %s", kid)
+		}
+		return fmt.Errorf("duplicate key: %s has the same public key as %s", kid, other)
+	}
+	v[string(pub)] = kid
+	return nil
+}
+
+// allSignatures lists the primary signature (if any) followed by the
+// additional signatures.
+func allSignatures(envelope Envelope) []Signature {
+	var sigs []Signature
+	if envelope.Sig != nil && *envelope.Sig != "" {
+		sigs = append(sigs, Signature{Kid: envelope.Kid, Alg: envelope.Alg, Sig: *envelope.Sig})
+	}
+	return append(sigs, envelope.Signatures...)
+}
@@ -0,0 +1,176 @@
+package core
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// -----------------------------------------------------------------------------
+// V1: Multi-signature
+// -----------------------------------------------------------------------------
+
+type testSigner struct {
+	kid  string
+	pub  ed25519.PublicKey
+	priv ed25519.PrivateKey
+}
+
+func newTestSigners(t *testing.T, kids ...string) ([]testSigner, map[string]ed25519.PublicKey) {
+	t.Helper()
+	signers := make([]testSigner, 0, len(kids))
+	keys := map[string]ed25519.PublicKey{}
+	for _, kid := range kids {
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signers = append(signers, testSigner{kid: kid, pub: pub, priv: priv})
+		keys[kid] = pub
+	}
+	return signers, keys
+}
+
+func TestV1_AppendSignature_ThresholdMet_OK(t *testing.T) {
+	signers, keys := newTestSigners(t, "officer-a", "officer-b", "officer-c")
+
+	env := baseEnvelopeJCS()
+	env.Kid = signers[0].kid
+	signed, err := SignEd25519(env, []byte(`{"release":"1.0"}`), signers[0].priv, false)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	signed, err = AppendSignatureEd25519(signed, signers[1].kid, signers[1].priv)
+	if err != nil {
+		t.Fatalf("append: %v", err)
+	}
+
+	// the primary signature is unaffected by appended signers
+	if err := VerifyEd25519(signed, signers[0].pub); err != nil {
+		t.Fatalf("verify primary: %v", err)
+	}
+
+	statuses, err := VerifyThresholdEd25519(signed, keys, 2)
+	if err != nil {
+		t.Fatalf("threshold: %v", err)
+	}
+	if len(statuses) != 2 || !statuses[0].OK || !statuses[1].OK {
+		t.Fatalf("unexpected statuses: %+v", statuses)
+	}
+
+	if _, err := VerifyThresholdEd25519(signed, keys, 3); err == nil {
+		t.Fatalf("want threshold error, got nil")
+	}
+}
+
+func TestV1_AppendSignature_DuplicateKid_Fail(t *testing.T) {
+	signers, _ := newTestSigners(t, "officer-a")
+
+	env := baseEnvelopeJCS()
+	env.Kid = signers[0].kid
+	signed, err := SignEd25519(env, []byte(`{"a":1}`), signers[0].priv, false)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	if _, err := AppendSignatureEd25519(signed, signers[0].kid, signers[0].priv); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+}
+
+func TestV1_VerifyThreshold_SameKeyTwoKids_Fail(t *testing.T) {
+	signers, keys := newTestSigners(t, "officer-a", "officer-b")
+	// the trust store lists officer-a's key a second time, under another kid
+	keys["officer-a-backup"] = signers[0].pub
+
+	env := baseEnvelopeJCS()
+	env.Kid = signers[0].kid
+	signed, err := SignEd25519(env, []byte(`{"a":1}`), signers[0].priv, false)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	signed, err = AppendSignatureEd25519(signed, "officer-a-backup", signers[0].priv)
+	if err != nil {
+		t.Fatalf("append: %v", err)
+	}
+
+	statuses, err := VerifyThresholdEd25519(signed, keys, 2)
+	if err == nil {
+		t.Fatalf("want threshold error, got nil")
+	}
+	if len(statuses) != 2 || !statuses[0].OK || statuses[1].OK || !strings.Contains(statuses[1].Error, "duplicate key") {
+		t.Fatalf("unexpected statuses: %+v", statuses)
+	}
+}
+
+func TestV1_VerifyThreshold_ReportsEachSignature(t *testing.T) {
+	signers, keys := newTestSigners(t, "officer-a", "officer-b")
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env := baseEnvelopeJCS()
+	env.Kid = signers[0].kid
+	signed, err := SignEd25519(env, []byte(`{"a":1}`), signers[0].priv, false)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	// signed by a key that is not in the trust store
+	signed, err = AppendSignatureEd25519(signed, "stranger", priv)
+	if err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	// claims officer-b but signed with the wrong key
+	signed, err = AppendSignatureEd25519(signed, "officer-b", priv)
+	if err != nil {
+		t.Fatalf("append: %v", err)
+	}
+
+	statuses, err := VerifyThresholdEd25519(signed, keys, 2)
+	if err == nil {
+		t.Fatalf("want threshold error, got nil")
+	}
+	if len(statuses) != 3 {
+		t.Fatalf("want 3 statuses, got %d", len(statuses))
+	}
+	if !statuses[0].OK {
+		t.Fatalf("primary should verify: %+v", statuses[0])
+	}
+	if statuses[1].OK || !strings.Contains(statuses[1].Error, "unknown kid") {
+		t.Fatalf("unexpected status for stranger: %+v", statuses[1])
+	}
+	if statuses[2].OK || statuses[2].Error != "signature verification failed" {
+		t.Fatalf("unexpected status for officer-b: %+v", statuses[2])
+	}
+}
+
+func TestV1_VerifyThresholdJSON_OK(t *testing.T) {
+	signers, keys := newTestSigners(t, "officer-a", "officer-b")
+
+	env := baseEnvelopeJCS()
+	env.Kid = signers[0].kid
+	signed, err := SignEd25519(env, []byte(`{"a":1}`), signers[0].priv, false)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	signed, err = AppendSignatureEd25519(signed, signers[1].kid, signers[1].priv)
+	if err != nil {
+		t.Fatalf("append: %v", err)
+	}
+
+	b, err := json.Marshal(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyThresholdEd25519JSON(b, keys, 2); err != nil {
+		t.Fatalf("threshold: %v", err)
+	}
+	// signatures are excluded from the signed message
+	if _, err := VerifyEd25519JSON(b, signers[0].pub); err != nil {
+		t.Fatalf("verify primary: %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/na0h/veriseal/canonical"
+)
+
+// ndjsonRecord is one non-blank line of an ndjson payload.
+type ndjsonRecord struct {
+	line int // 1-based line number in the payload
+	data []byte
+}
+
+// splitNDJSON returns the non-blank lines of payload. A line that holds only
+// spaces, tabs or a CR is blank, so CRLF files and trailing newlines are
+// accepted.
+func splitNDJSON(payload []byte) []ndjsonRecord {
+	var records []ndjsonRecord
+	for i, line := range bytes.Split(payload, []byte("\n")) {
+		if len(bytes.Trim(line, " \t\r")) == 0 {
+			continue
+		}
+		records = append(records, ndjsonRecord{line: i + 1, data: line})
+	}
+	return records
+}
+
+// canonicalNDJSONRecords returns the JCS form of every record. Like a jcs
+// payload, a record must be a JSON object or array.
+func canonicalNDJSONRecords(payload []byte) ([]ndjsonRecord, error) {
+	records := splitNDJSON(payload)
+	for i, r := range records {
+		b, err := canonical.Canonicalize(r.data)
+		if err != nil {
+			return nil, fmt.Errorf("payload_encoding=ndjson but line %d is not a JSON object or array: %w", r.line, err)
+		}
+		records[i].data = b
+	}
+	return records, nil
+}
+
+// NormalizeNDJSON canonicalizes every non-blank line of an NDJSON / JSON
+// Lines payload with JCS and joins the results with "\n" (no trailing
+// newline). Each record must be a JSON object or array.
+func NormalizeNDJSON(payload []byte) ([]byte, error) {
+	records, err := canonicalNDJSONRecords(payload)
+	if err != nil {
+		return nil, err
+	}
+	lines := make([][]byte, len(records))
+	for i, r := range records {
+		lines[i] = r.data
+	}
+	return bytes.Join(lines, []byte("\n")), nil
+}
+
+// NDJSONRecordHashes returns the payload_record_hashes of an ndjson payload:
+// the sha256 (base64) of each canonical record, in order.
+func NDJSONRecordHashes(payload []byte) ([]string, error) {
+	records, err := canonicalNDJSONRecords(payload)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]string, len(records))
+	for i, r := range records {
+		hashes[i] = hashNormalizedPayload(r.data)
+	}
+	return hashes, nil
+}
+
+// validateNDJSONIJSON applies the I-JSON checks of signing to every record.
+func validateNDJSONIJSON(payload []byte) error {
+	for _, r := range splitNDJSON(payload) {
+		if err := canonical.ValidateIJSON(r.data); errors.Is(err, canonical.ErrNotIJSON) {
+			return fmt.Errorf("line %d: %w", r.line, err)
+		}
+	}
+	return nil
+}
+
+const (
+	RecordChanged = "changed"
+	RecordAdded   = "added"
+	RecordMissing = "missing"
+)
+
+// RecordMismatch is one ndjson record that differs from
+// payload_record_hashes. Record is the 0-based record index (of the payload
+// being verified for added records, of the signed records otherwise); Line
+// is the 1-based line in the payload being verified (0 for missing records).
+type RecordMismatch struct {
+	Record int    `json:"record"`
+	Line   int    `json:"line,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// ExplainNDJSONMismatch locates the records of payload that differ from the
+// envelope's payload_record_hashes. Records shared at the start and at the
+// end are skipped first, so a single inserted or removed record is reported
+// as added or missing instead of shifting every record after it; the rest is
+// compared position by position.
+func ExplainNDJSONMismatch(envelope Envelope, payload []byte) ([]RecordMismatch, error) {
+	if envelope.PayloadEncoding != V1PayloadEncodingNDJSON {
+		return nil, fmt.Errorf("cannot explain payload_encoding=%s (ndjson only)", envelope.PayloadEncoding)
+	}
+	want := envelope.PayloadRecordHashes
+	if want == nil {
+		return nil, fmt.Errorf("envelope has no payload_record_hashes (sign with record hashes to locate differing records)")
+	}
+	records, err := canonicalNDJSONRecords(payload)
+	if err != nil {
+		return nil, err
+	}
+	got := make([]string, len(records))
+	for i, r := range records {
+		got[i] = hashNormalizedPayload(r.data)
+	}
+
+	head := 0
+	for head < len(want) && head < len(got) && want[head] == got[head] {
+		head++
+	}
+	tail := 0
+	for tail < len(want)-head && tail < len(got)-head && want[len(want)-1-tail] == got[len(got)-1-tail] {
+		tail++
+	}
+
+	var diffs []RecordMismatch
+	w, g := want[head:len(want)-tail], got[head:len(got)-tail]
+	for i := 0; i < len(w) || i < len(g); i++ {
+		switch {
+		case i >= len(w):
+			diffs = append(diffs, RecordMismatch{Record: head + i, Line: records[head+i].line, Reason: RecordAdded})
+		case i >= len(g):
+			diffs = append(diffs, RecordMismatch{Record: head + i, Reason: RecordMissing})
+		case w[i] != g[i]:
+			diffs = append(diffs, RecordMismatch{Record: head + i, Line: records[head+i].line, Reason: RecordChanged})
+		}
+	}
+	return diffs, nil
+}
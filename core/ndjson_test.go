@@ -0,0 +1,149 @@
+package core
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/na0h/veriseal/canonical"
+)
+
+// -----------------------------------------------------------------------------
+// payload_encoding=ndjson
+// -----------------------------------------------------------------------------
+
+func baseEnvelopeNDJSON() Envelope {
+	env, err := NewEnvelopeTemplateV1("demo-1", V1PayloadEncodingNDJSON)
+	if err != nil {
+		panic(err)
+	}
+	return env
+}
+
+func TestNDJSON_Normalize_OK(t *testing.T) {
+	in := []byte("{\"b\":1, \"a\":2}\r\n\n  \n[1, 2.0]\n{}\n\n")
+	got, err := NormalizeNDJSON(in)
+	if err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+	if want := "{\"a\":2,\"b\":1}\n[1,2]\n{}"; string(got) != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestNDJSON_InvalidLine_Fail(t *testing.T) {
+	_, err := NormalizeNDJSON([]byte("{}\n\n{\"a\":\n"))
+	if err == nil {
+		t.Fatalf("want error, got nil")
+	}
+	if want := "payload_encoding=ndjson but line 3 is not a JSON object or array: invalid json"; err.Error() != want {
+		t.Fatalf("got %q, want %q", err, want)
+	}
+
+	_, err = NormalizeNDJSON([]byte("{}\n\"scalar\"\n"))
+	if !errors.Is(err, canonical.ErrTopLevelNotObjArray) {
+		t.Fatalf("want ErrTopLevelNotObjArray, got %v", err)
+	}
+	if want := "payload_encoding=ndjson but line 2 is not a JSON object or array: top-level JSON must be object or array"; err.Error() != want {
+		t.Fatalf("got %q, want %q", err, want)
+	}
+}
+
+func TestNDJSON_SignVerify_OK(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed, err := SignEd25519(baseEnvelopeNDJSON(), []byte("{\"id\":1}\n{\"id\":2}\n"), priv, false)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if signed.PayloadRecordHashes != nil {
+		t.Fatalf("payload_record_hashes set without RecordHashes")
+	}
+	if err := VerifyEd25519(signed, pub); err != nil {
+		t.Fatalf("verify sig: %v", err)
+	}
+	if err := VerifyPayloadHash(signed, []byte("{ \"id\": 1 }\r\n\r\n{ \"id\": 2 }")); err != nil {
+		t.Fatalf("verify payload: %v", err)
+	}
+	if err := VerifyPayloadHash(signed, []byte("{\"id\":2}\n{\"id\":1}\n")); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+}
+
+func TestNDJSON_Sign_NonIJSONRecord_Fail(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = SignEd25519(baseEnvelopeNDJSON(), []byte("{}\n{\"a\":1,\"a\":2}\n"), priv, false)
+	if err == nil {
+		t.Fatalf("want error, got nil")
+	}
+}
+
+func TestNDJSON_RecordHashes_NotNDJSON_Fail(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = SignEd25519WithOptions(baseEnvelopeJCS(), []byte(`{}`), priv, SignOptions{RecordHashes: true})
+	if err == nil {
+		t.Fatalf("want error, got nil")
+	}
+
+	env := baseEnvelopeJCS()
+	env.PayloadRecordHashes = []string{"x"}
+	if err := ValidateEnvelope(env); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+}
+
+func TestNDJSON_Explain(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := SignEd25519WithOptions(baseEnvelopeNDJSON(), []byte("{\"id\":0}\n{\"id\":1}\n{\"id\":2}\n{\"id\":3}\n"), priv, SignOptions{RecordHashes: true})
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if len(signed.PayloadRecordHashes) != 4 {
+		t.Fatalf("want 4 record hashes, got %d", len(signed.PayloadRecordHashes))
+	}
+
+	cases := []struct {
+		name    string
+		payload string
+		want    []RecordMismatch
+	}{
+		{"match", "{\"id\":0}\n{\"id\":1}\n{\"id\":2}\n{\"id\":3}\n", nil},
+		{"changed", "{\"id\":0}\n{\"id\":1}\n\n{\"id\":9}\n{\"id\":3}\n", []RecordMismatch{{Record: 2, Line: 4, Reason: RecordChanged}}},
+		{"added", "{\"id\":0}\n{\"id\":9}\n{\"id\":1}\n{\"id\":2}\n{\"id\":3}\n", []RecordMismatch{{Record: 1, Line: 2, Reason: RecordAdded}}},
+		{"missing", "{\"id\":0}\n{\"id\":2}\n{\"id\":3}\n", []RecordMismatch{{Record: 1, Reason: RecordMissing}}},
+		{"scattered", "{\"id\":9}\n{\"id\":1}\n{\"id\":2}\n{\"id\":8}\n", []RecordMismatch{
+			{Record: 0, Line: 1, Reason: RecordChanged},
+			{Record: 3, Line: 4, Reason: RecordChanged},
+		}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ExplainNDJSONMismatch(signed, []byte(tc.payload))
+			if err != nil {
+				t.Fatalf("explain: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+
+	signed.PayloadRecordHashes = nil
+	if _, err := ExplainNDJSONMismatch(signed, []byte("{}")); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+}
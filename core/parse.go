@@ -0,0 +1,182 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/na0h/veriseal/canonical"
+)
+
+// DefaultEnvelopeLimits bounds the envelope, DSSE and JWS JSON accepted by
+// the parsers in this package, before anything is decoded; attached payloads
+// count towards it. The WithLimits variants take explicit limits instead.
+var DefaultEnvelopeLimits = canonical.Limits{
+	MaxDepth:        64,
+	MaxSize:         64 << 20,
+	MaxKeys:         10000,
+	MaxStringLength: 64 << 20,
+}
+
+// ParseEnvelopeStrict decodes an envelope JSON object and rejects anything
+// that json.Unmarshal would silently accept: unknown fields, duplicate keys
+// (at any depth) and keys that only match a field case-insensitively.
+// Input beyond DefaultEnvelopeLimits is rejected first.
+func ParseEnvelopeStrict(b []byte) (Envelope, error) {
+	return ParseEnvelopeStrictWithLimits(b, DefaultEnvelopeLimits)
+}
+
+// ParseEnvelopeStrictWithLimits is ParseEnvelopeStrict with explicit limits.
+func ParseEnvelopeStrictWithLimits(b []byte, l canonical.Limits) (Envelope, error) {
+	if err := canonical.CheckLimits(b, l); err != nil {
+		return Envelope{}, err
+	}
+	if err := checkNoDuplicateKeys(b); err != nil {
+		return Envelope{}, err
+	}
+
+	var raw any
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return Envelope{}, err
+	}
+	if _, ok := raw.(map[string]any); !ok {
+		return Envelope{}, fmt.Errorf("envelope must be a JSON object")
+	}
+	if err := checkKnownFields(raw, reflect.TypeOf(Envelope{}), ""); err != nil {
+		return Envelope{}, err
+	}
+
+	var env Envelope
+	if err := json.Unmarshal(b, &env); err != nil {
+		return Envelope{}, err
+	}
+	return env, nil
+}
+
+// checkNoDuplicateKeys walks the JSON token stream and fails on the first
+// object that repeats a key.
+func checkNoDuplicateKeys(b []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := walkNoDuplicateKeys(dec, ""); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return fmt.Errorf("invalid json: trailing data")
+	}
+	return nil
+}
+
+func walkNoDuplicateKeys(dec *json.Decoder, ptr string) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("invalid json: %w", err)
+	}
+	d, ok := tok.(json.Delim)
+	if !ok {
+		return nil
+	}
+
+	switch d {
+	case '{':
+		seen := map[string]struct{}{}
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return fmt.Errorf("invalid json: %w", err)
+			}
+			key := keyTok.(string)
+			if _, dup := seen[key]; dup {
+				return fmt.Errorf("duplicate field: %s", jsonPointer(ptr, key))
+			}
+			seen[key] = struct{}{}
+			if err := walkNoDuplicateKeys(dec, jsonPointer(ptr, key)); err != nil {
+				return err
+			}
+		}
+	case '[':
+		for i := 0; dec.More(); i++ {
+			if err := walkNoDuplicateKeys(dec, jsonPointer(ptr, strconv.Itoa(i))); err != nil {
+				return err
+			}
+		}
+	}
+	// closing delimiter
+	if _, err := dec.Token(); err != nil {
+		return fmt.Errorf("invalid json: %w", err)
+	}
+	return nil
+}
+
+// checkKnownFields matches every object key against the exact json tag names
+// of t, recursing into nested structs and slices.
+func checkKnownFields(v any, t reflect.Type, ptr string) error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(json.RawMessage(nil)) {
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		fields := jsonFields(t)
+		for key, val := range obj {
+			ft, ok := fields[key]
+			if !ok {
+				for name := range fields {
+					if strings.EqualFold(name, key) {
+						return fmt.Errorf("field name case mismatch: %s (want %q)", jsonPointer(ptr, key), name)
+					}
+				}
+				return fmt.Errorf("unknown field: %s", jsonPointer(ptr, key))
+			}
+			if err := checkKnownFields(val, ft, jsonPointer(ptr, key)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		arr, ok := v.([]any)
+		if !ok {
+			return nil
+		}
+		for i, elem := range arr {
+			if err := checkKnownFields(elem, t.Elem(), jsonPointer(ptr, strconv.Itoa(i))); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// jsonPointer appends one reference token to an RFC 6901 JSON pointer.
+func jsonPointer(ptr, token string) string {
+	return ptr + "/" + canonical.EscapePointerToken(token)
+}
@@ -0,0 +1,125 @@
+package core
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/na0h/veriseal/canonical"
+)
+
+// -----------------------------------------------------------------------------
+// V1: Strict parsing
+// -----------------------------------------------------------------------------
+
+func signedJSONForTest(t *testing.T) ([]byte, ed25519.PublicKey) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := SignEd25519(baseEnvelopeJCS(), []byte(`{"a":1}`), priv, false)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	b, err := json.Marshal(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b, pub
+}
+
+func TestV1_ParseEnvelopeStrict_OK(t *testing.T) {
+	b, pub := signedJSONForTest(t)
+
+	env, err := ParseEnvelopeStrict(b)
+	if err != nil {
+		t.Fatalf("ParseEnvelopeStrict: %v", err)
+	}
+	if env.Kid != "demo-1" {
+		t.Fatalf("kid: want %q, got %q", "demo-1", env.Kid)
+	}
+	if _, err := VerifyEd25519JSON(b, pub); err != nil {
+		t.Fatalf("VerifyEd25519JSON: %v", err)
+	}
+}
+
+func TestV1_ParseEnvelopeStrict_UnknownField_Fail(t *testing.T) {
+	b, _ := signedJSONForTest(t)
+	b = []byte(strings.Replace(string(b), `{`, `{"extra":"x",`, 1))
+
+	_, err := ParseEnvelopeStrict(b)
+	if err == nil {
+		t.Fatalf("want error, got nil")
+	}
+	if !strings.Contains(err.Error(), "unknown field: /extra") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestV1_ParseEnvelopeStrict_DuplicateKey_Fail(t *testing.T) {
+	b, _ := signedJSONForTest(t)
+	b = []byte(strings.Replace(string(b), `{`, `{"kid":"other",`, 1))
+
+	_, err := ParseEnvelopeStrict(b)
+	if err == nil {
+		t.Fatalf("want error, got nil")
+	}
+	if !strings.Contains(err.Error(), "duplicate field: /kid") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestV1_ParseEnvelopeStrict_CaseVariantKey_Fail(t *testing.T) {
+	b, _ := signedJSONForTest(t)
+	b = []byte(strings.Replace(string(b), `"kid"`, `"Kid"`, 1))
+
+	_, err := ParseEnvelopeStrict(b)
+	if err == nil {
+		t.Fatalf("want error, got nil")
+	}
+	if !strings.Contains(err.Error(), "case mismatch") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestV1_VerifyEd25519JSON_UsesReceivedObject(t *testing.T) {
+	b, pub := signedJSONForTest(t)
+
+	// An explicit null is dropped by json.Unmarshal, so the struct-based
+	// verifier accepts it; the received object differs from what was signed.
+	b = []byte(strings.Replace(string(b), `{`, `{"iat":null,`, 1))
+
+	var env Envelope
+	if err := json.Unmarshal(b, &env); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyEd25519(env, pub); err != nil {
+		t.Fatalf("VerifyEd25519: %v", err)
+	}
+
+	if _, err := VerifyEd25519JSON(b, pub); err == nil {
+		t.Fatalf("want verify failure, got nil")
+	}
+}
+
+func TestV1_ParseEnvelopeStrict_LimitExceeded_Fail(t *testing.T) {
+	b, _ := signedJSONForTest(t)
+
+	l := DefaultEnvelopeLimits
+	l.MaxSize = int64(len(b) - 1)
+	if _, err := ParseEnvelopeStrictWithLimits(b, l); !errors.Is(err, canonical.ErrTooLarge) {
+		t.Fatalf("want ErrTooLarge, got %v", err)
+	}
+	if _, err := ParseEnvelopeStrict(b); err != nil {
+		t.Fatalf("ParseEnvelopeStrict: %v", err)
+	}
+
+	deep := []byte(`{"v":1,"payload":` + strings.Repeat("[", 100) + strings.Repeat("]", 100) + `}`)
+	if _, err := ParseEnvelopeStrict(deep); !errors.Is(err, canonical.ErrTooDeep) {
+		t.Fatalf("want ErrTooDeep, got %v", err)
+	}
+}
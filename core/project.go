@@ -0,0 +1,210 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/na0h/veriseal/canonical"
+)
+
+// ProjectPayload returns the JCS form of the parts of a JSON payload covered
+// by payload_include and payload_exclude (RFC 6901 JSON pointers).
+//
+// With include pointers, only the values they point to are kept, together
+// with the objects and arrays that lead to them; without, the whole document
+// is kept. The values exclude pointers point to are then removed. Pointers to
+// values that do not exist select nothing. Array elements are addressed by
+// index; kept elements stay in document order and removed elements close up.
+func ProjectPayload(payload []byte, include, exclude []string) ([]byte, error) {
+	inc, err := newPointerTrie(include)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload_include: %w", err)
+	}
+	exc, err := newPointerTrie(exclude)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload_exclude: %w", err)
+	}
+
+	// Canonicalize first: it rejects duplicate member names, which the
+	// decoder would resolve silently (the last one wins).
+	canon, err := canonical.Canonicalize(payload)
+	if err != nil {
+		return nil, jcsPayloadError(err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(canon))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("payload_encoding=jcs but payload is not valid JSON")
+	}
+
+	if len(include) > 0 {
+		doc, _ = includeJSON(doc, inc, true)
+	}
+	if len(exclude) > 0 {
+		doc = excludeJSON(doc, exc)
+	}
+
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	out, err := canonical.Canonicalize(b)
+	if err != nil {
+		return nil, fmt.Errorf("payload_encoding=jcs but payload is not valid JSON")
+	}
+	return out, nil
+}
+
+// hasProjection reports whether the envelope covers only part of its payload.
+func hasProjection(envelope Envelope) bool {
+	return len(envelope.PayloadInclude) > 0 || len(envelope.PayloadExclude) > 0
+}
+
+func validatePointers(field string, ptrs []string) error {
+	for _, p := range ptrs {
+		if _, err := parsePointer(p); err != nil {
+			return fmt.Errorf("invalid %s: %w", field, err)
+		}
+	}
+	return nil
+}
+
+// parsePointer splits an RFC 6901 JSON pointer into unescaped tokens.
+func parsePointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if p[0] != '/' {
+		return nil, fmt.Errorf("json pointer must start with \"/\": %q", p)
+	}
+	tokens := strings.Split(p[1:], "/")
+	for i, t := range tokens {
+		for j := 0; j < len(t); j++ {
+			if t[j] == '~' && (j+1 == len(t) || (t[j+1] != '0' && t[j+1] != '1')) {
+				return nil, fmt.Errorf("invalid escape in json pointer: %q", p)
+			}
+		}
+		t = strings.ReplaceAll(t, "~1", "/")
+		tokens[i] = strings.ReplaceAll(t, "~0", "~")
+	}
+	return tokens, nil
+}
+
+// pointerTrie merges JSON pointers by token; end marks a pointer that ends
+// at this node.
+type pointerTrie struct {
+	end      bool
+	children map[string]*pointerTrie
+}
+
+func newPointerTrie(ptrs []string) (*pointerTrie, error) {
+	root := &pointerTrie{}
+	for _, p := range ptrs {
+		tokens, err := parsePointer(p)
+		if err != nil {
+			return nil, err
+		}
+		n := root
+		for _, t := range tokens {
+			if n.children == nil {
+				n.children = map[string]*pointerTrie{}
+			}
+			c, ok := n.children[t]
+			if !ok {
+				c = &pointerTrie{}
+				n.children[t] = c
+			}
+			n = c
+		}
+		n.end = true
+	}
+	return root, nil
+}
+
+// arrayIndex parses an RFC 6901 array index (no sign, no leading zeros).
+func arrayIndex(token string, n int) (int, bool) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i >= n || strconv.Itoa(i) != token {
+		return 0, false
+	}
+	return i, true
+}
+
+// sortedIndexes returns the children of t that are valid indexes into an
+// array of length n, in ascending order.
+func (t *pointerTrie) sortedIndexes(n int) []int {
+	var idx []int
+	for token := range t.children {
+		if i, ok := arrayIndex(token, n); ok {
+			idx = append(idx, i)
+		}
+	}
+	slices.Sort(idx)
+	return idx
+}
+
+// includeJSON keeps the parts of v selected by t. It reports false when
+// nothing below v is selected, except at the root, which keeps its (possibly
+// empty) object or array.
+func includeJSON(v any, t *pointerTrie, root bool) (any, bool) {
+	if t.end {
+		return v, true
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		out := map[string]any{}
+		for k, c := range t.children {
+			if cv, ok := v[k]; ok {
+				if pv, ok := includeJSON(cv, c, false); ok {
+					out[k] = pv
+				}
+			}
+		}
+		return out, len(out) > 0 || root
+	case []any:
+		out := []any{}
+		for _, i := range t.sortedIndexes(len(v)) {
+			if pv, ok := includeJSON(v[i], t.children[strconv.Itoa(i)], false); ok {
+				out = append(out, pv)
+			}
+		}
+		return out, len(out) > 0 || root
+	}
+	return nil, false
+}
+
+// excludeJSON removes the parts of v selected by t.
+func excludeJSON(v any, t *pointerTrie) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, cv := range v {
+			c, ok := t.children[k]
+			switch {
+			case !ok:
+				out[k] = cv
+			case !c.end:
+				out[k] = excludeJSON(cv, c)
+			}
+		}
+		return out
+	case []any:
+		out := make([]any, 0, len(v))
+		for i, cv := range v {
+			c, ok := t.children[strconv.Itoa(i)]
+			switch {
+			case !ok:
+				out = append(out, cv)
+			case !c.end:
+				out = append(out, excludeJSON(cv, c))
+			}
+		}
+		return out
+	}
+	return v
+}
@@ -0,0 +1,108 @@
+package core
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+)
+
+// -----------------------------------------------------------------------------
+// payload_include / payload_exclude
+// -----------------------------------------------------------------------------
+
+func TestProjectPayload(t *testing.T) {
+	doc := []byte(`{"id":"a1","updated_at":"2026-01-01","meta":{"views":3,"owner":"x","a/b":1},"items":[{"n":1,"ts":9},{"n":2,"ts":8},{"n":3,"ts":7}],"note":null}`)
+
+	cases := []struct {
+		name    string
+		include []string
+		exclude []string
+		want    string
+	}{
+		{"exclude member", nil, []string{"/updated_at", "/meta/views"},
+			`{"id":"a1","items":[{"n":1,"ts":9},{"n":2,"ts":8},{"n":3,"ts":7}],"meta":{"a/b":1,"owner":"x"},"note":null}`},
+		{"include members", []string{"/id", "/meta/owner", "/note"}, nil,
+			`{"id":"a1","meta":{"owner":"x"},"note":null}`},
+		{"escaped token", []string{"/meta/a~1b"}, nil,
+			`{"meta":{"a/b":1}}`},
+		{"array elements", []string{"/items/2", "/items/0/n"}, nil,
+			`{"items":[{"n":1},{"n":3,"ts":7}]}`},
+		{"exclude inside array", nil, []string{"/items/0/ts", "/items/1"},
+			`{"id":"a1","items":[{"n":1},{"n":3,"ts":7}],"meta":{"a/b":1,"owner":"x","views":3},"note":null,"updated_at":"2026-01-01"}`},
+		{"include then exclude", []string{"/meta"}, []string{"/meta/views"},
+			`{"meta":{"a/b":1,"owner":"x"}}`},
+		{"missing paths", []string{"/nope", "/id/x", "/items/01"}, []string{"/gone"},
+			`{}`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ProjectPayload(doc, tc.include, tc.exclude)
+			if err != nil {
+				t.Fatalf("project: %v", err)
+			}
+			if string(got) != tc.want {
+				t.Fatalf("got  %s\nwant %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestProjectPayload_VolatileFields_OK(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env := baseEnvelopeJCS()
+	env.PayloadExclude = []string{"/updated_at", "/stats/views"}
+	signed, err := SignEd25519(env, []byte(`{"id":1,"updated_at":"t1","stats":{"views":1,"likes":2}}`), priv, false)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if err := VerifyEd25519(signed, pub); err != nil {
+		t.Fatalf("verify sig: %v", err)
+	}
+	if err := VerifyPayloadHash(signed, []byte(`{"stats":{"likes":2,"views":57},"id":1,"updated_at":"t9"}`)); err != nil {
+		t.Fatalf("verify payload: %v", err)
+	}
+	if err := VerifyPayloadHash(signed, []byte(`{"id":1,"updated_at":"t1","stats":{"views":1,"likes":3}}`)); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+
+	// the pointer lists are signed
+	signed.PayloadExclude = append(signed.PayloadExclude, "/stats/likes")
+	if err := VerifyEd25519(signed, pub); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+}
+
+func TestProjectPayload_Validate_Fail(t *testing.T) {
+	cases := []struct {
+		name string
+		edit func(*Envelope)
+	}{
+		{"not jcs", func(e *Envelope) { e.PayloadEncoding = V1PayloadEncodingRaw; e.PayloadExclude = []string{"/a"} }},
+		{"no leading slash", func(e *Envelope) { e.PayloadInclude = []string{"a"} }},
+		{"bad escape", func(e *Envelope) { e.PayloadExclude = []string{"/a~2"} }},
+		{"exclude root", func(e *Envelope) { e.PayloadExclude = []string{""} }},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			env := baseEnvelopeJCS()
+			tc.edit(&env)
+			if err := ValidateEnvelope(env); err == nil {
+				t.Fatalf("want error, got nil")
+			}
+		})
+	}
+}
+
+func TestProjectPayload_DuplicateKey_Fail(t *testing.T) {
+	// decoded on its own, {"a":1,"a":2} would become {"a":2}
+	if _, err := ProjectPayload([]byte(`{"a":1,"a":2,"t":0}`), nil, []string{"/t"}); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+	if _, err := ProjectPayload([]byte(`{"k":{"a":1,"a":2}}`), []string{"/k"}, nil); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+}
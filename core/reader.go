@@ -0,0 +1,216 @@
+package core
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/na0h/veriseal/canonical"
+)
+
+// ComputePayloadHashReader is ComputePayloadHash for a payload read from r.
+// It also returns the size of the normalized payload, for payload_size.
+//
+// raw payloads are hashed as they are read, jcs payloads are canonicalized
+// straight into the hash (see ComputePayloadHashJCSReader) and tar entries
+// are hashed one by one, so memory stays constant however large the payload
+// is. The other encodings need the whole payload to normalize it and read r
+// completely first; text is rejected, as in NormalizePayloadBytes.
+func ComputePayloadHashReader(r io.Reader, payloadEncoding string) (string, int64, error) {
+	switch payloadEncoding {
+	case V1PayloadEncodingText:
+		return "", 0, errTextNeedsSteps
+	case V1PayloadEncodingRaw:
+		h := sha256.New()
+		n, err := io.Copy(h, r)
+		if err != nil {
+			return "", 0, err
+		}
+		return base64.StdEncoding.EncodeToString(h.Sum(nil)), n, nil
+	case V1PayloadEncodingJCS:
+		return ComputePayloadHashJCSReader(r)
+	case V1PayloadEncodingTar:
+		hash, n, _, err := hashTarStream(r, false)
+		return hash, n, err
+	}
+
+	payload, err := io.ReadAll(r)
+	if err != nil {
+		return "", 0, err
+	}
+	norm, err := NormalizePayloadBytes(payload, payloadEncoding)
+	if err != nil {
+		return "", 0, err
+	}
+	return hashNormalizedPayload(norm), int64(len(norm)), nil
+}
+
+// streamsPayload reports whether the payload of envelope can be hashed
+// without loading it: raw and tar always, jcs unless part of the document
+// is selected.
+func streamsPayload(envelope Envelope) bool {
+	switch envelope.PayloadEncoding {
+	case V1PayloadEncodingRaw, V1PayloadEncodingTar:
+		return true
+	case V1PayloadEncodingJCS:
+		return !hasProjection(envelope)
+	}
+	return false
+}
+
+// SignReader is SignEd25519WithOptions for a payload read from r. raw, tar
+// and jcs payloads are hashed as they are read, jcs payloads being checked
+// for I-JSON on the way (unless AllowNonIJSON is set); everything else, and
+// jcs with payload_include / payload_exclude, is read into memory and signed
+// as usual.
+func SignReader(envelope Envelope, r io.Reader, priv ed25519.PrivateKey, opts SignOptions) (Envelope, error) {
+	if err := ValidateEnvelope(envelope); err != nil {
+		return Envelope{}, err
+	}
+	if !streamsPayload(envelope) {
+		payload, err := io.ReadAll(r)
+		if err != nil {
+			return Envelope{}, err
+		}
+		return SignEd25519WithOptions(envelope, payload, priv, opts)
+	}
+	envelope.PayloadRecordHashes = nil
+	envelope.PayloadEntryHashes = nil
+	var hash string
+	var size int64
+	var err error
+	switch {
+	case envelope.PayloadEncoding == V1PayloadEncodingTar:
+		hash, size, envelope.PayloadEntryHashes, err = hashTarStream(r, opts.RecordHashes)
+	case opts.RecordHashes:
+		return Envelope{}, errRecordHashes
+	case envelope.PayloadEncoding == V1PayloadEncodingJCS && !opts.AllowNonIJSON:
+		hash, size, err = hashJCSStream(r, canonical.CanonicalizeStreamStrict)
+	default:
+		hash, size, err = ComputePayloadHashReader(r, envelope.PayloadEncoding)
+	}
+	if err != nil {
+		return Envelope{}, err
+	}
+	envelope.PayloadHash = hash
+	envelope.PayloadSize = &size
+	return signUnsignedEd25519(withIat(envelope, opts), priv)
+}
+
+// VerifyingReader passes a payload through unchanged while checking it
+// against payload_hash (and payload_size, when present). Read returns the
+// mismatch error instead of io.EOF, so a consumer that copies the payload
+// elsewhere learns at the end whether it can trust what it got.
+type VerifyingReader struct {
+	r        io.Reader
+	envelope Envelope
+	sink     io.Writer
+	finish   func() (string, int64, error)
+	buf      *bytes.Buffer
+	err      error
+}
+
+// NewVerifyingReader returns a VerifyingReader for the payload of envelope
+// read from r. raw, jcs and tar payloads are checked as they stream
+// through; other encodings (and jcs with payload_include / payload_exclude)
+// keep a copy of the payload to check it at EOF. Read the payload to the
+// end: a jcs or tar VerifyingReader holds a goroutine until then.
+func NewVerifyingReader(envelope Envelope, r io.Reader) (*VerifyingReader, error) {
+	if envelope.PayloadHash == "" {
+		return nil, fmt.Errorf("missing payload_hash")
+	}
+	if envelope.PayloadEncoding == "" {
+		return nil, fmt.Errorf("missing payload_encoding")
+	}
+	if !slices.Contains(SupportedPayloadEncodings, envelope.PayloadEncoding) {
+		return nil, fmt.Errorf("unsupported payload_encoding: %s", envelope.PayloadEncoding)
+	}
+
+	v := &VerifyingReader{r: r, envelope: envelope}
+	switch {
+	case envelope.PayloadEncoding == V1PayloadEncodingRaw:
+		h := sha256.New()
+		cw := &countingWriter{w: h}
+		v.sink = cw
+		v.finish = func() (string, int64, error) {
+			return base64.StdEncoding.EncodeToString(h.Sum(nil)), cw.n, nil
+		}
+	case envelope.PayloadEncoding == V1PayloadEncodingJCS && !hasProjection(envelope),
+		envelope.PayloadEncoding == V1PayloadEncodingTar:
+		// The canonicalizer (or tar reader) pulls from a pipe fed by Read.
+		// When it stops early on invalid input, closing the pipe fails the
+		// next write.
+		pr, pw := io.Pipe()
+		type result struct {
+			hash string
+			n    int64
+			err  error
+		}
+		done := make(chan result, 1)
+		go func() {
+			hash, n, err := ComputePayloadHashReader(pr, envelope.PayloadEncoding)
+			pr.CloseWithError(err)
+			done <- result{hash, n, err}
+		}()
+		v.sink = pw
+		var res *result
+		v.finish = func() (string, int64, error) {
+			if res == nil {
+				pw.Close()
+				r := <-done
+				res = &r
+			}
+			return res.hash, res.n, res.err
+		}
+	default:
+		v.buf = &bytes.Buffer{}
+		v.sink = v.buf
+	}
+	return v, nil
+}
+
+func (v *VerifyingReader) Read(p []byte) (int, error) {
+	if v.err != nil {
+		return 0, v.err
+	}
+	n, err := v.r.Read(p)
+	if n > 0 {
+		if _, werr := v.sink.Write(p[:n]); werr != nil {
+			// the canonicalizer gave up: report its error
+			if _, _, v.err = v.finish(); v.err == nil {
+				v.err = werr
+			}
+			return n, v.err
+		}
+	}
+	if err == io.EOF {
+		v.err = v.check()
+		if v.err == nil {
+			v.err = io.EOF
+		}
+		return n, v.err
+	}
+	return n, err
+}
+
+// check compares the payload read so far with the envelope.
+func (v *VerifyingReader) check() error {
+	if v.buf != nil {
+		return VerifyPayloadHash(v.envelope, v.buf.Bytes())
+	}
+	hash, n, err := v.finish()
+	if err != nil {
+		return err
+	}
+	if s := v.envelope.PayloadSize; s != nil && *s != n {
+		return fmt.Errorf("size mismatch (expected %d, got %d)", *s, n)
+	}
+	if hash != v.envelope.PayloadHash {
+		return fmt.Errorf("payload hash mismatch")
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package core
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// -----------------------------------------------------------------------------
+// Streaming: ComputePayloadHashReader / SignReader / VerifyingReader
+// -----------------------------------------------------------------------------
+
+func TestComputePayloadHashReader_MatchesInMemory(t *testing.T) {
+	cases := []struct {
+		enc     string
+		payload string
+	}{
+		{V1PayloadEncodingRaw, "\x00binary\r\nbytes"},
+		{V1PayloadEncodingJCS, `{"b":[1.0,2],"a":"é"}`},
+		{V1PayloadEncodingNDJSON, "{\"b\":1,\"a\":2}\n[3]\n"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.enc, func(t *testing.T) {
+			want, err := ComputePayloadHash([]byte(tc.payload), tc.enc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			norm, err := NormalizePayloadBytes([]byte(tc.payload), tc.enc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, n, err := ComputePayloadHashReader(iotest.OneByteReader(strings.NewReader(tc.payload)), tc.enc)
+			if err != nil {
+				t.Fatalf("ComputePayloadHashReader: %v", err)
+			}
+			if got != want || n != int64(len(norm)) {
+				t.Fatalf("want %s/%d, got %s/%d", want, len(norm), got, n)
+			}
+		})
+	}
+}
+
+func TestSignReader_MatchesSignEd25519(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := bytes.Repeat([]byte("artifact "), 10000)
+	signed, err := SignReader(baseEnvelopeRaw(), bytes.NewReader(payload), priv, SignOptions{})
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if err := VerifyEd25519(signed, pub); err != nil {
+		t.Fatalf("verify sig: %v", err)
+	}
+	if err := VerifyPayloadHash(signed, payload); err != nil {
+		t.Fatalf("verify payload: %v", err)
+	}
+	if *signed.PayloadSize != int64(len(payload)) {
+		t.Fatalf("payload_size: got %d, want %d", *signed.PayloadSize, len(payload))
+	}
+}
+
+func TestSignReader_JCSStillChecksIJSON(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// streamed, with the same errors as in memory
+	for _, payload := range []string{`{"a":1,"a":2}`, `[{"n":9007199254740993}]`, `{"s":"\ud800"}`, `{"a":}`} {
+		_, want := SignEd25519WithOptions(baseEnvelopeJCS(), []byte(payload), priv, SignOptions{})
+		_, err := SignReader(baseEnvelopeJCS(), strings.NewReader(payload), priv, SignOptions{})
+		if err == nil || want == nil || err.Error() != want.Error() {
+			t.Fatalf("%s: want %v, got %v", payload, want, err)
+		}
+	}
+	if _, err := SignReader(baseEnvelopeRaw(), strings.NewReader("x"), priv, SignOptions{RecordHashes: true}); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+}
+
+// The streaming and in-memory jcs paths must agree byte for byte: sign with
+// one, verify with the other.
+func TestSignReader_JCSCrossPath(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := SignOptions{AllowNonIJSON: true}
+
+	for _, payload := range []string{
+		"{\"a\":\"\xff\"}",
+		`{"\u00e9":"\ud83d\ude00","e\u0301":[1.0,-0]}`,
+		`["\ud800\u0041"]`,
+	} {
+		inMemory, err := SignEd25519WithOptions(baseEnvelopeJCS(), []byte(payload), priv, opts)
+		if err != nil {
+			t.Fatalf("%q: SignEd25519WithOptions: %v", payload, err)
+		}
+		streamed, err := SignReader(baseEnvelopeJCS(), strings.NewReader(payload), priv, opts)
+		if err != nil {
+			t.Fatalf("%q: SignReader: %v", payload, err)
+		}
+		if inMemory.PayloadHash != streamed.PayloadHash || *inMemory.PayloadSize != *streamed.PayloadSize {
+			t.Fatalf("%q: hashes differ: %s/%d vs %s/%d", payload, inMemory.PayloadHash, *inMemory.PayloadSize, streamed.PayloadHash, *streamed.PayloadSize)
+		}
+
+		if err := VerifyEd25519(streamed, pub); err != nil {
+			t.Fatalf("%q: VerifyEd25519: %v", payload, err)
+		}
+		if err := VerifyPayloadHash(streamed, []byte(payload)); err != nil {
+			t.Fatalf("%q: VerifyPayloadHash: %v", payload, err)
+		}
+		vr, err := NewVerifyingReader(inMemory, strings.NewReader(payload))
+		if err != nil {
+			t.Fatalf("%q: NewVerifyingReader: %v", payload, err)
+		}
+		if _, err := io.Copy(io.Discard, vr); err != nil {
+			t.Fatalf("%q: VerifyingReader: %v", payload, err)
+		}
+	}
+
+	// rejected by both
+	for _, payload := range []string{`{"a":1,"a":2}`, `{"a":"\ud800"}`} {
+		if _, err := SignEd25519WithOptions(baseEnvelopeJCS(), []byte(payload), priv, opts); err == nil {
+			t.Fatalf("%q: SignEd25519WithOptions: want error, got nil", payload)
+		}
+		if _, err := SignReader(baseEnvelopeJCS(), strings.NewReader(payload), priv, opts); err == nil {
+			t.Fatalf("%q: SignReader: want error, got nil", payload)
+		}
+	}
+}
+
+func TestVerifyingReader(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jcs := baseEnvelopeJCS()
+	cases := []struct {
+		name     string
+		envelope Envelope
+		signed   string
+		read     string
+		wantErr  bool
+	}{
+		{"raw ok", baseEnvelopeRaw(), "payload bytes", "payload bytes", false},
+		{"raw changed", baseEnvelopeRaw(), "payload bytes", "payload bytez", true},
+		{"raw truncated", baseEnvelopeRaw(), "payload bytes", "payload", true},
+		{"jcs ok", jcs, `[{"b":2,"a":1}]`, `[ {"a":1.0, "b":2} ]`, false},
+		{"jcs changed", jcs, `[{"b":2,"a":1}]`, `[{"a":1,"b":3}]`, true},
+		{"jcs invalid", jcs, `[{"b":2,"a":1}]`, `[{"a":1,` + strings.Repeat(" ", 10000) + `}]`, true},
+		{"text buffered", baseEnvelopeText(), "a\r\nb\n", "a\nb\n", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			signed, err := SignEd25519(tc.envelope, []byte(tc.signed), priv, false)
+			if err != nil {
+				t.Fatalf("sign: %v", err)
+			}
+			vr, err := NewVerifyingReader(signed, iotest.HalfReader(strings.NewReader(tc.read)))
+			if err != nil {
+				t.Fatalf("NewVerifyingReader: %v", err)
+			}
+			var out bytes.Buffer
+			_, err = io.Copy(&out, vr)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("want error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("copy: %v", err)
+			}
+			if out.String() != tc.read {
+				t.Fatalf("passed through %q, want %q", out.String(), tc.read)
+			}
+			// the error (or io.EOF) sticks
+			if _, err := vr.Read(make([]byte, 1)); !errors.Is(err, io.EOF) {
+				t.Fatalf("want io.EOF, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package core
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// -----------------------------------------------------------------------------
+// payload_ref / PayloadResolver
+// -----------------------------------------------------------------------------
+
+func writeCASBlob(t *testing.T, dir string, b []byte) string {
+	t.Helper()
+	sum := sha256.Sum256(b)
+	digest := hex.EncodeToString(sum[:])
+	if err := os.MkdirAll(filepath.Join(dir, "sha256"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "sha256", digest), b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return "sha256:" + digest
+}
+
+func TestResolve_FileRef_OK(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "payload.bin")
+	payload := []byte("hello")
+	if err := os.WriteFile(path, payload, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	env := baseEnvelopeRaw()
+	env.PayloadRef = "file://" + filepath.ToSlash(path)
+	signed, err := SignEd25519(env, payload, priv, false)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if err := VerifyEd25519(signed, pub); err != nil {
+		t.Fatalf("verify sig: %v", err)
+	}
+
+	r := SchemeResolver{"file": FileResolver{Root: filepath.Dir(path)}}
+	got, err := ResolvePayload(signed, r)
+	if err != nil {
+		t.Fatalf("ResolvePayload: %v", err)
+	}
+	if err := VerifyPayloadHash(signed, got); err != nil {
+		t.Fatalf("VerifyPayloadHash: %v", err)
+	}
+}
+
+func TestResolve_FileRef_OutsideRoot_Fail(t *testing.T) {
+	root := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(outside, []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{
+		outside,
+		filepath.Join(root, "..", filepath.Base(filepath.Dir(outside)), "secret"),
+		filepath.Join(root, "link"),
+	} {
+		env := baseEnvelopeRaw()
+		env.PayloadRef = "file://" + filepath.ToSlash(path)
+		if _, err := ResolvePayload(env, FileResolver{Root: root}); err == nil {
+			t.Fatalf("%s: want error, got nil", path)
+		}
+	}
+
+	env := baseEnvelopeRaw()
+	env.PayloadRef = "file://" + filepath.ToSlash(outside)
+	if _, err := ResolvePayload(env, FileResolver{}); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+}
+
+func TestResolve_MaxSize_Fail(t *testing.T) {
+	dir := t.TempDir()
+	payload := []byte(`{"a":"` + strings.Repeat("x", 100) + `"}`)
+	env := baseEnvelopeJCS()
+	env.PayloadRef = writeCASBlob(t, dir, payload)
+
+	if _, err := ResolvePayloadWithLimit(env, CASResolver{Dir: dir}, int64(len(payload))); err != nil {
+		t.Fatalf("ResolvePayloadWithLimit: %v", err)
+	}
+	_, err := ResolvePayloadWithLimit(env, CASResolver{Dir: dir}, 64)
+	if err == nil {
+		t.Fatalf("want error, got nil")
+	}
+	if !strings.Contains(err.Error(), "exceeds 64 bytes") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestResolve_CASRef_OK(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	payload := []byte(`{"a":1}`)
+	env := baseEnvelopeJCS()
+	env.PayloadRef = writeCASBlob(t, dir, payload)
+	signed, err := SignEd25519(env, payload, priv, false)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	got, err := ResolvePayload(signed, CASResolver{Dir: dir})
+	if err != nil {
+		t.Fatalf("ResolvePayload: %v", err)
+	}
+	if err := VerifyPayloadHash(signed, got); err != nil {
+		t.Fatalf("VerifyPayloadHash: %v", err)
+	}
+}
+
+func TestResolve_CASRef_CorruptBlob_Fail(t *testing.T) {
+	dir := t.TempDir()
+	ref := writeCASBlob(t, dir, []byte("original"))
+	blob := filepath.Join(dir, "sha256", strings.TrimPrefix(ref, "sha256:"))
+	if err := os.WriteFile(blob, []byte("tampered"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	env := baseEnvelopeRaw()
+	env.PayloadRef = ref
+	_, err := ResolvePayload(env, CASResolver{Dir: dir})
+	if err == nil {
+		t.Fatalf("want error, got nil")
+	}
+	if !strings.Contains(err.Error(), "content address mismatch") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestResolve_UnknownScheme_Fail(t *testing.T) {
+	env := baseEnvelopeRaw()
+	env.PayloadRef = "s3://bucket/key"
+	_, err := ResolvePayload(env, SchemeResolver{"file": FileResolver{}})
+	if err == nil {
+		t.Fatalf("want error, got nil")
+	}
+	if !strings.Contains(err.Error(), "no resolver") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestResolve_Validate_RelativeRef_Fail(t *testing.T) {
+	env := baseEnvelopeRaw()
+	env.PayloadRef = "payload.bin"
+	if err := ValidateEnvelope(env); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+}
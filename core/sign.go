@@ -4,6 +4,8 @@ import (
 	"crypto/ed25519"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/na0h/veriseal/canonical"
@@ -11,25 +13,112 @@ import (
 
 var nowUnix = func() int64 { return time.Now().Unix() }
 
+var errRecordHashes = errors.New("record hashes require payload_encoding=ndjson, tar or zip")
+
+// SignOptions controls SignEd25519WithOptions and SignCOSEWithOptions.
+type SignOptions struct {
+	// SetIat sets iat right before signing.
+	SetIat bool
+	// AllowNonIJSON skips the I-JSON (RFC 7493) checks on jcs and ndjson
+	// payloads: duplicate member names, surrogates and integers beyond 2^53-1.
+	AllowNonIJSON bool
+	// RecordHashes sets payload_record_hashes on ndjson envelopes and
+	// payload_entry_hashes on tar and zip envelopes.
+	RecordHashes bool
+}
+
+// SignEd25519 computes payload_hash and signs the envelope. v2 envelopes
+// always carry iat, so it is set when missing even if setIat is false.
+// jcs payloads must be I-JSON; see SignEd25519WithOptions.
 func SignEd25519(envelope Envelope, payloadBytes []byte, priv ed25519.PrivateKey, setIat bool) (Envelope, error) {
-	if err := ValidateEnvelopeV1(envelope); err != nil {
+	return SignEd25519WithOptions(envelope, payloadBytes, priv, SignOptions{SetIat: setIat})
+}
+
+// SignEd25519WithOptions is SignEd25519 with explicit options.
+func SignEd25519WithOptions(envelope Envelope, payloadBytes []byte, priv ed25519.PrivateKey, opts SignOptions) (Envelope, error) {
+	unsigned, err := prepareUnsigned(envelope, payloadBytes, opts)
+	if err != nil {
+		return Envelope{}, err
+	}
+	return signUnsignedEd25519(unsigned, priv)
+}
+
+// prepareUnsigned validates the template and fills in everything that is
+// signed: payload_hash, payload_size, payload_record_hashes when requested
+// and, when requested or required, iat.
+func prepareUnsigned(envelope Envelope, payloadBytes []byte, opts SignOptions) (Envelope, error) {
+	if err := ValidateEnvelope(envelope); err != nil {
 		return Envelope{}, err
 	}
 
-	h, err := ComputePayloadHash(payloadBytes, envelope.PayloadEncoding)
+	if opts.RecordHashes && envelope.PayloadEncoding != V1PayloadEncodingNDJSON && !isArchiveEncoding(envelope.PayloadEncoding) {
+		return Envelope{}, errRecordHashes
+	}
+
+	norm, err := normalizeForSigning(envelope, payloadBytes, !opts.AllowNonIJSON)
 	if err != nil {
 		return Envelope{}, err
 	}
-	envelope.PayloadHash = h
+	size := int64(len(norm))
+	envelope.PayloadHash = hashNormalizedPayload(norm)
+	envelope.PayloadSize = &size
+	envelope.PayloadRecordHashes = nil
+	envelope.PayloadEntryHashes = nil
+	if opts.RecordHashes {
+		if isArchiveEncoding(envelope.PayloadEncoding) {
+			envelope.PayloadEntryHashes, err = ArchiveEntryHashes(payloadBytes, envelope.PayloadEncoding)
+		} else {
+			envelope.PayloadRecordHashes, err = NDJSONRecordHashes(payloadBytes)
+		}
+		if err != nil {
+			return Envelope{}, err
+		}
+	}
 
-	unsigned := envelope
-	unsigned.Sig = nil
+	return withIat(envelope, opts), nil
+}
 
-	if setIat {
+// normalizeForSigning is NormalizeEnvelopePayload that, when strict, also
+// requires jcs and ndjson payloads to be I-JSON. Signing is strict so that
+// no two readers can see different data under the signed hash; verifying
+// stays lenient for existing envelopes.
+func normalizeForSigning(envelope Envelope, payload []byte, strict bool) ([]byte, error) {
+	if strict {
+		switch envelope.PayloadEncoding {
+		case V1PayloadEncodingJCS:
+			if !hasProjection(envelope) {
+				b, err := canonical.CanonicalizeStrict(payload)
+				if err != nil {
+					return nil, jcsPayloadError(err)
+				}
+				return b, nil
+			}
+			if err := canonical.ValidateIJSON(payload); errors.Is(err, canonical.ErrNotIJSON) {
+				return nil, fmt.Errorf("invalid payload: %w", err)
+			}
+		case V1PayloadEncodingNDJSON:
+			if err := validateNDJSONIJSON(payload); err != nil {
+				return nil, fmt.Errorf("invalid payload: %w", err)
+			}
+		}
+	}
+	return NormalizeEnvelopePayload(envelope, payload)
+}
+
+// withIat strips the unsigned members and sets iat when requested or
+// required.
+func withIat(envelope Envelope, opts SignOptions) Envelope {
+	unsigned := unsignedEnvelope(envelope)
+
+	if opts.SetIat || (unsigned.V == Version2 && unsigned.Iat == nil) {
 		iat := nowUnix()
 		unsigned.Iat = &iat
 	}
+	return unsigned
+}
 
+// signUnsignedEd25519 signs an envelope whose payload_hash is already set.
+func signUnsignedEd25519(unsigned Envelope, priv ed25519.PrivateKey) (Envelope, error) {
 	b, err := json.Marshal(unsigned)
 	if err != nil {
 		return Envelope{}, err
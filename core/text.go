@@ -0,0 +1,95 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	TextCharsetUTF8 = "utf-8"
+	TextBOMStrip    = "strip"
+	TextNewlineLF   = "lf"
+	TextUnicodeNFC  = "nfc"
+)
+
+// TextNormalization records the steps applied to a payload_encoding=text
+// payload before hashing, in this order: decode as Charset (invalid input is
+// rejected), strip a leading byte order mark, convert CRLF and CR line
+// endings to Newline, apply Unicode normalization form Unicode and,
+// optionally, trim trailing whitespace from every line.
+type TextNormalization struct {
+	Charset                string `json:"charset"`
+	BOM                    string `json:"bom"`
+	Newline                string `json:"newline"`
+	Unicode                string `json:"unicode"`
+	TrimTrailingWhitespace bool   `json:"trim_trailing_whitespace,omitempty"`
+}
+
+// DefaultTextNormalization is the only set of required steps currently
+// defined; TrimTrailingWhitespace may be turned on.
+func DefaultTextNormalization() TextNormalization {
+	return TextNormalization{
+		Charset: TextCharsetUTF8,
+		BOM:     TextBOMStrip,
+		Newline: TextNewlineLF,
+		Unicode: TextUnicodeNFC,
+	}
+}
+
+func validateTextNormalization(t TextNormalization) error {
+	switch {
+	case t.Charset != TextCharsetUTF8:
+		return fmt.Errorf("unsupported payload_text.charset: %s", t.Charset)
+	case t.BOM != TextBOMStrip:
+		return fmt.Errorf("unsupported payload_text.bom: %s", t.BOM)
+	case t.Newline != TextNewlineLF:
+		return fmt.Errorf("unsupported payload_text.newline: %s", t.Newline)
+	case t.Unicode != TextUnicodeNFC:
+		return fmt.Errorf("unsupported payload_text.unicode: %s", t.Unicode)
+	}
+	return nil
+}
+
+// NormalizeText applies the text normalization steps t to payload.
+func NormalizeText(payload []byte, t TextNormalization) ([]byte, error) {
+	if err := validateTextNormalization(t); err != nil {
+		return nil, err
+	}
+	if !utf8.Valid(payload) {
+		return nil, fmt.Errorf("payload_encoding=text but payload is not valid UTF-8")
+	}
+
+	b := bytes.TrimPrefix(payload, []byte("\uFEFF"))
+	b = bytes.ReplaceAll(b, []byte("\r\n"), []byte("\n"))
+	b = bytes.ReplaceAll(b, []byte("\r"), []byte("\n"))
+	b = norm.NFC.Bytes(b)
+
+	if t.TrimTrailingWhitespace {
+		lines := bytes.Split(b, []byte("\n"))
+		for i, line := range lines {
+			lines[i] = bytes.TrimRightFunc(line, unicode.IsSpace)
+		}
+		b = bytes.Join(lines, []byte("\n"))
+	}
+	return b, nil
+}
+
+// NormalizeEnvelopePayload normalizes payload as declared by the envelope:
+// payload_encoding together with its parameters (payload_text,
+// payload_include and payload_exclude).
+func NormalizeEnvelopePayload(envelope Envelope, payload []byte) ([]byte, error) {
+	if envelope.PayloadEncoding == V1PayloadEncodingText {
+		if envelope.PayloadText == nil {
+			return nil, fmt.Errorf("missing payload_text (required for payload_encoding=text)")
+		}
+		return NormalizeText(payload, *envelope.PayloadText)
+	}
+	if envelope.PayloadEncoding == V1PayloadEncodingJCS && hasProjection(envelope) {
+		return ProjectPayload(payload, envelope.PayloadInclude, envelope.PayloadExclude)
+	}
+	return NormalizePayloadBytes(payload, envelope.PayloadEncoding)
+}
@@ -0,0 +1,116 @@
+package core
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"strings"
+	"testing"
+)
+
+// -----------------------------------------------------------------------------
+// payload_encoding=text
+// -----------------------------------------------------------------------------
+
+func baseEnvelopeText() Envelope {
+	env, err := NewEnvelopeTemplateV1("demo-1", V1PayloadEncodingText)
+	if err != nil {
+		panic(err)
+	}
+	return env
+}
+
+func TestText_CrossPlatform_OK(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// LF, NFC on one side; BOM, CRLF, decomposed "é" on the other
+	unix := []byte("caf\u00e9\nline 2\n")
+	windows := []byte("\uFEFFcafe\u0301\r\nline 2\r\n")
+
+	signed, err := SignEd25519(baseEnvelopeText(), unix, priv, false)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if err := VerifyEd25519(signed, pub); err != nil {
+		t.Fatalf("verify sig: %v", err)
+	}
+	if err := VerifyPayloadHash(signed, windows); err != nil {
+		t.Fatalf("verify payload: %v", err)
+	}
+}
+
+func TestText_TrailingWhitespace(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env := baseEnvelopeText()
+	signed, err := SignEd25519(env, []byte("a\nb\n"), priv, false)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if err := VerifyPayloadHash(signed, []byte("a  \nb\t\n")); err == nil {
+		t.Fatalf("want error without trim_trailing_whitespace, got nil")
+	}
+
+	env.PayloadText.TrimTrailingWhitespace = true
+	signed, err = SignEd25519(env, []byte("a\nb\n"), priv, false)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if err := VerifyPayloadHash(signed, []byte("a  \r\nb\t\r\n")); err != nil {
+		t.Fatalf("verify payload: %v", err)
+	}
+}
+
+// Without the envelope there is no payload_text, so the encoding-only APIs
+// cannot know whether to trim trailing whitespace.
+func TestText_EncodingOnly_Rejected(t *testing.T) {
+	if _, err := ComputePayloadHash([]byte("a \n"), V1PayloadEncodingText); err == nil {
+		t.Fatalf("ComputePayloadHash: want error, got nil")
+	}
+	if _, _, err := ComputePayloadHashReader(strings.NewReader("a \n"), V1PayloadEncodingText); err == nil {
+		t.Fatalf("ComputePayloadHashReader: want error, got nil")
+	}
+	if _, err := NewSubject("notes.txt", []byte("a \n"), V1PayloadEncodingText); err == nil {
+		t.Fatalf("NewSubject: want error, got nil")
+	}
+}
+
+func TestText_InvalidUTF8_Fail(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = SignEd25519(baseEnvelopeText(), []byte{'a', 0xff}, priv, false)
+	if err == nil {
+		t.Fatalf("want error, got nil")
+	}
+	if !strings.Contains(err.Error(), "not valid UTF-8") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestText_Validate_PayloadText(t *testing.T) {
+	env := baseEnvelopeText()
+	env.PayloadText = nil
+	if err := ValidateEnvelope(env); err == nil {
+		t.Fatalf("want error for missing payload_text, got nil")
+	}
+
+	env = baseEnvelopeText()
+	env.PayloadText.Unicode = "nfd"
+	if err := ValidateEnvelope(env); err == nil {
+		t.Fatalf("want error for unsupported unicode form, got nil")
+	}
+
+	env = baseEnvelopeRaw()
+	tn := DefaultTextNormalization()
+	env.PayloadText = &tn
+	if err := ValidateEnvelope(env); err == nil {
+		t.Fatalf("want error for payload_text with raw, got nil")
+	}
+}
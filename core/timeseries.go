@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"slices"
 
 	"github.com/google/uuid"
 	"github.com/na0h/veriseal/canonical"
@@ -38,8 +39,7 @@ func NewTimeseriesEnvelopeTemplateV1(kid string, payloadEncoding string) (Envelo
 }
 
 func UnsignedHashV1(env Envelope) (string, error) {
-	unsigned := env
-	unsigned.Sig = nil
+	unsigned := unsignedEnvelope(env)
 
 	b, err := json.Marshal(unsigned)
 	if err != nil {
@@ -75,6 +75,12 @@ func NextTimeseriesEnvelopeTemplateV1(prev Envelope) (Envelope, error) {
 	next.TsSessionID = &sid
 	next.TsSeq = &seq
 	next.TsPrev = &prevHash
+	if prev.PayloadText != nil {
+		t := *prev.PayloadText
+		next.PayloadText = &t
+	}
+	next.PayloadInclude = slices.Clone(prev.PayloadInclude)
+	next.PayloadExclude = slices.Clone(prev.PayloadExclude)
 
 	next.Iat = nil
 	return next, nil
@@ -3,10 +3,13 @@ package core
 import (
 	"crypto/ed25519"
 	"crypto/rand"
+	"errors"
 	"math"
 	"regexp"
 	"strings"
 	"testing"
+
+	"github.com/na0h/veriseal/canonical"
 )
 
 func baseEnvelopeJCS() Envelope {
@@ -170,6 +173,186 @@ func TestV1_Verify_RawVsJCS_Mismatch_Fail(t *testing.T) {
 	}
 }
 
+func TestV1_Verify_PayloadSize_Truncated_Fail(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := []byte{0x10, 0x20, 0x30, 0x40}
+	signed, err := SignEd25519(baseEnvelopeRaw(), payload, priv, false)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if signed.PayloadSize == nil || *signed.PayloadSize != 4 {
+		t.Fatalf("want payload_size 4, got %v", signed.PayloadSize)
+	}
+
+	err = VerifyPayloadHash(signed, payload[:3])
+	if err == nil {
+		t.Fatalf("want error, got nil")
+	}
+	if err.Error() != "size mismatch (expected 4, got 3)" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestV1_Verify_PayloadSize_Absent_OK(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// envelopes signed before payload_size existed still verify
+	payload := []byte(`{"b":2,"a":1}`)
+	env := baseEnvelopeJCS()
+	h, err := ComputePayloadHash(payload, env.PayloadEncoding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env.PayloadHash = h
+	signed, err := signUnsignedEd25519(env, priv)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	if err := VerifyEd25519(signed, pub); err != nil {
+		t.Fatalf("verify sig: %v", err)
+	}
+	if err := VerifyPayloadHash(signed, payload); err != nil {
+		t.Fatalf("verify payload: %v", err)
+	}
+}
+
+func TestV1_PayloadHashJCSReader_MatchesInMemory(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := `[{"b":2,"a":1},{"z":"\u00e9","y":[1.0,2e0]}]`
+	signed, err := SignEd25519(baseEnvelopeJCS(), []byte(payload), priv, false)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	h, n, err := ComputePayloadHashJCSReader(strings.NewReader(payload))
+	if err != nil {
+		t.Fatalf("ComputePayloadHashJCSReader: %v", err)
+	}
+	if h != signed.PayloadHash || n != *signed.PayloadSize {
+		t.Fatalf("want %s/%d, got %s/%d", signed.PayloadHash, *signed.PayloadSize, h, n)
+	}
+	if err := VerifyPayloadHashJCSReader(signed, strings.NewReader(payload)); err != nil {
+		t.Fatalf("VerifyPayloadHashJCSReader: %v", err)
+	}
+	if err := VerifyPayloadHashJCSReader(signed, strings.NewReader(`[{"a":1,"b":3},{}]`)); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+}
+
+func TestV1_JCSPayload_LimitErrorKept(t *testing.T) {
+	deep := strings.Repeat("[", 200) + strings.Repeat("]", 200)
+
+	_, err := ComputePayloadHash([]byte(deep), V1PayloadEncodingJCS)
+	if !errors.Is(err, canonical.ErrTooDeep) {
+		t.Fatalf("ComputePayloadHash: want ErrTooDeep, got %v", err)
+	}
+	_, _, err = ComputePayloadHashJCSReader(strings.NewReader(deep))
+	if !errors.Is(err, canonical.ErrTooDeep) {
+		t.Fatalf("ComputePayloadHashJCSReader: want ErrTooDeep, got %v", err)
+	}
+
+	// syntax errors still read the same on both paths
+	for _, payload := range []string{`{"a":}`, `"str"`} {
+		_, err := ComputePayloadHash([]byte(payload), V1PayloadEncodingJCS)
+		if err == nil || err.Error() != "payload_encoding=jcs but payload is not valid JSON" {
+			t.Fatalf("%s: unexpected error: %v", payload, err)
+		}
+		_, _, err2 := ComputePayloadHashJCSReader(strings.NewReader(payload))
+		if err2 == nil || err2.Error() != err.Error() {
+			t.Fatalf("%s: want %v, got %v", payload, err, err2)
+		}
+	}
+}
+
+func TestV1_CBORPayload_KeyOrderIndependent_OK(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// {"b": 1, "a": 1.5} with a double vs {"a": 1.5, "b": 1} with a half float
+	producerA := []byte{0xa2, 0x61, 'b', 0x01, 0x61, 'a', 0xfb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}
+	producerB := []byte{0xa2, 0x61, 'a', 0xf9, 0x3e, 0x00, 0x61, 'b', 0x01}
+
+	env := baseEnvelopeRaw()
+	env.PayloadEncoding = V1PayloadEncodingCBOR
+	signed, err := SignEd25519(env, producerA, priv, false)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if err := VerifyEd25519(signed, pub); err != nil {
+		t.Fatalf("verify sig: %v", err)
+	}
+	if err := VerifyPayloadHash(signed, producerB); err != nil {
+		t.Fatalf("verify payload: %v", err)
+	}
+
+	// indefinite-length map
+	if err := VerifyPayloadHash(signed, []byte{0xbf, 0x61, 'a', 0x01, 0xff}); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+}
+
+func TestV1_YAMLPayload_FormattingIndependent_OK(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	original := []byte("kind: ConfigMap\ndata:\n  b: \"2\"\n  a: one # comment\n---\nreplicas: 3\n")
+	reformatted := []byte("---\ndata: {a: 'one', b: '2'}\nkind: ConfigMap\n---\nreplicas: 3\n")
+
+	env := baseEnvelopeRaw()
+	env.PayloadEncoding = V1PayloadEncodingYAML
+	signed, err := SignEd25519(env, original, priv, false)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if err := VerifyEd25519(signed, pub); err != nil {
+		t.Fatalf("verify sig: %v", err)
+	}
+	if err := VerifyPayloadHash(signed, reformatted); err != nil {
+		t.Fatalf("verify payload: %v", err)
+	}
+
+	// same data through an alias
+	if err := VerifyPayloadHash(signed, []byte("kind: &k ConfigMap\nx: *k\n")); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+}
+
+func TestV1_Sign_NonIJSONPayload_Fail(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := []byte(`{"a":{"id":12345678901234567890}}`)
+	_, err = SignEd25519(baseEnvelopeJCS(), payload, priv, false)
+	if err == nil {
+		t.Fatalf("want error, got nil")
+	}
+	if !strings.Contains(err.Error(), `at "/a/id"`) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := SignEd25519WithOptions(baseEnvelopeJCS(), payload, priv, SignOptions{AllowNonIJSON: true}); err != nil {
+		t.Fatalf("sign with AllowNonIJSON: %v", err)
+	}
+}
+
 // -----------------------------------------------------------------------------
 // V1: Validation
 // -----------------------------------------------------------------------------
@@ -181,7 +364,7 @@ func TestV1_Validate_VersionInvalid_Fail(t *testing.T) {
 	}
 
 	env := baseEnvelopeJCS()
-	env.V = 2
+	env.V = 99
 
 	_, err = SignEd25519(env, []byte(`{"a":1}`), priv, false)
 	if err == nil {
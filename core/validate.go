@@ -3,12 +3,58 @@ package core
 import (
 	"fmt"
 	"math"
+	"net/url"
+	"slices"
 )
 
+// SupportedVersions lists the envelope versions this package can validate,
+// sign and verify.
+var SupportedVersions = []int{Version1, Version2}
+
+// SupportedPayloadEncodings lists the payload_encoding values this package
+// can normalize.
+var SupportedPayloadEncodings = []string{V1PayloadEncodingJCS, V1PayloadEncodingRaw, V1PayloadEncodingText, V1PayloadEncodingCBOR, V1PayloadEncodingYAML, V1PayloadEncodingNDJSON, V1PayloadEncodingSD, V1PayloadEncodingTar, V1PayloadEncodingZip}
+
+// ValidateEnvelope dispatches to the validator of the envelope's version.
+func ValidateEnvelope(envelope Envelope) error {
+	switch envelope.V {
+	case Version1:
+		return ValidateEnvelopeV1(envelope)
+	case Version2:
+		return ValidateEnvelopeV2(envelope)
+	default:
+		return fmt.Errorf("invalid version: %d (supported: %v)", envelope.V, SupportedVersions)
+	}
+}
+
 func ValidateEnvelopeV1(envelope Envelope) error {
 	if envelope.V != Version1 {
 		return fmt.Errorf("invalid version: %d", envelope.V)
 	}
+	if envelope.MigratedFrom != nil {
+		return fmt.Errorf("migrated_from requires v%d", Version2)
+	}
+	return validateEnvelopeFields(envelope)
+}
+
+// ValidateEnvelopeV2 validates a v2 envelope. v2 has the same fields as v1
+// plus migrated_from; signing a v2 envelope always sets iat.
+func ValidateEnvelopeV2(envelope Envelope) error {
+	if envelope.V != Version2 {
+		return fmt.Errorf("invalid version: %d", envelope.V)
+	}
+	if l := envelope.MigratedFrom; l != nil {
+		if l.V != Version1 {
+			return fmt.Errorf("unsupported migrated_from.v: %d", l.V)
+		}
+		if l.Hash == "" {
+			return fmt.Errorf("missing migrated_from.hash")
+		}
+	}
+	return validateEnvelopeFields(envelope)
+}
+
+func validateEnvelopeFields(envelope Envelope) error {
 	if envelope.Alg != V1AlgEd25519 {
 		return fmt.Errorf("unsupported alg: %s", envelope.Alg)
 	}
@@ -18,12 +64,50 @@ func ValidateEnvelopeV1(envelope Envelope) error {
 	if envelope.PayloadEncoding == "" {
 		return fmt.Errorf("missing payload_encoding")
 	}
-	if envelope.PayloadEncoding != V1PayloadEncodingJCS && envelope.PayloadEncoding != V1PayloadEncodingRaw {
+	if !slices.Contains(SupportedPayloadEncodings, envelope.PayloadEncoding) {
 		return fmt.Errorf("unsupported payload_encoding: %s", envelope.PayloadEncoding)
 	}
+	if envelope.PayloadEncoding == V1PayloadEncodingText {
+		if envelope.PayloadText == nil {
+			return fmt.Errorf("missing payload_text (required for payload_encoding=text)")
+		}
+		if err := validateTextNormalization(*envelope.PayloadText); err != nil {
+			return err
+		}
+	} else if envelope.PayloadText != nil {
+		return fmt.Errorf("payload_text requires payload_encoding=text")
+	}
 	if envelope.PayloadHashAlg != V1PayloadHashAlgSHA256 {
 		return fmt.Errorf("unsupported payload_hash_alg: %s", envelope.PayloadHashAlg)
 	}
+	if envelope.PayloadInclude != nil || envelope.PayloadExclude != nil {
+		if envelope.PayloadEncoding != V1PayloadEncodingJCS {
+			return fmt.Errorf("payload_include and payload_exclude require payload_encoding=jcs")
+		}
+		if err := validatePointers("payload_include", envelope.PayloadInclude); err != nil {
+			return err
+		}
+		if err := validatePointers("payload_exclude", envelope.PayloadExclude); err != nil {
+			return err
+		}
+		if slices.Contains(envelope.PayloadExclude, "") {
+			return fmt.Errorf("payload_exclude cannot exclude the whole document (\"\")")
+		}
+	}
+	if envelope.PayloadRecordHashes != nil && envelope.PayloadEncoding != V1PayloadEncodingNDJSON {
+		return fmt.Errorf("payload_record_hashes requires payload_encoding=ndjson")
+	}
+	if envelope.PayloadEntryHashes != nil && !isArchiveEncoding(envelope.PayloadEncoding) {
+		return fmt.Errorf("payload_entry_hashes requires payload_encoding=tar or zip")
+	}
+	if envelope.PayloadSize != nil && *envelope.PayloadSize < 0 {
+		return fmt.Errorf("invalid payload_size: %d", *envelope.PayloadSize)
+	}
+	if envelope.PayloadRef != "" {
+		if u, err := url.Parse(envelope.PayloadRef); err != nil || u.Scheme == "" {
+			return fmt.Errorf("invalid payload_ref (want an absolute URI): %s", envelope.PayloadRef)
+		}
+	}
 	return nil
 }
 
@@ -5,11 +5,20 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 
 	"github.com/na0h/veriseal/canonical"
 )
 
+// errTextNeedsSteps rejects payload_encoding=text where only the encoding
+// is known: how a text payload is normalized is recorded in payload_text.
+var errTextNeedsSteps = errors.New("payload_encoding=text needs its payload_text steps: normalize it with the envelope")
+
+// NormalizePayloadBytes normalizes payload as payloadEncoding alone
+// describes. text payloads depend on payload_text as well and are rejected:
+// use NormalizeEnvelopePayload (or NormalizeText) for them.
 func NormalizePayloadBytes(payload []byte, payloadEncoding string) ([]byte, error) {
 	switch payloadEncoding {
 	case "":
@@ -19,21 +28,137 @@ func NormalizePayloadBytes(payload []byte, payloadEncoding string) ([]byte, erro
 	case V1PayloadEncodingJCS:
 		b, err := canonical.Canonicalize(payload)
 		if err != nil {
-			return nil, fmt.Errorf("payload_encoding=jcs but payload is not valid JSON")
+			return nil, jcsPayloadError(err)
 		}
 		return b, nil
+	case V1PayloadEncodingText:
+		return nil, errTextNeedsSteps
+	case V1PayloadEncodingCBOR:
+		b, err := canonical.CanonicalizeCBOR(payload)
+		if err != nil {
+			return nil, fmt.Errorf("payload_encoding=cbor but payload is not valid CBOR: %w", err)
+		}
+		return b, nil
+	case V1PayloadEncodingYAML:
+		b, err := canonical.CanonicalizeYAML(payload)
+		if err != nil {
+			return nil, fmt.Errorf("payload_encoding=yaml but payload is not valid YAML: %w", err)
+		}
+		return b, nil
+	case V1PayloadEncodingNDJSON:
+		return NormalizeNDJSON(payload)
+	case V1PayloadEncodingSD:
+		return normalizeSD(payload)
+	case V1PayloadEncodingTar, V1PayloadEncodingZip:
+		return NormalizeArchive(payload, payloadEncoding)
 	default:
 		return nil, fmt.Errorf("unsupported payload_encoding: %s", payloadEncoding)
 	}
 }
 
+// ComputePayloadHash returns the payload_hash of payload normalized by
+// NormalizePayloadBytes; text payloads are rejected there.
 func ComputePayloadHash(payload []byte, payloadEncoding string) (string, error) {
 	norm, err := NormalizePayloadBytes(payload, payloadEncoding)
 	if err != nil {
 		return "", err
 	}
+	return hashNormalizedPayload(norm), nil
+}
+
+// ComputePayloadHashJCSReader is ComputePayloadHash for payload_encoding=jcs
+// that canonicalizes r straight into the hash instead of loading it, for
+// JSON payloads too large to hold in memory. It also returns the size of the
+// canonical form, for payload_size.
+func ComputePayloadHashJCSReader(r io.Reader) (string, int64, error) {
+	return hashJCSStream(r, canonical.CanonicalizeStream)
+}
+
+// hashJCSStream canonicalizes r with canonicalize (CanonicalizeStream or
+// CanonicalizeStreamStrict) straight into the hash.
+func hashJCSStream(r io.Reader, canonicalize func(io.Writer, io.Reader) error) (string, int64, error) {
+	h := sha256.New()
+	cw := &countingWriter{w: h}
+	er := &errReader{r: r}
+	if err := canonicalize(cw, er); err != nil {
+		if er.err != nil {
+			return "", 0, er.err
+		}
+		return "", 0, jcsPayloadError(err)
+	}
+	return base64.StdEncoding.EncodeToString(h.Sum(nil)), cw.n, nil
+}
+
+// jcsPayloadError describes a jcs payload that could not be canonicalized.
+// Limit and I-JSON errors keep their cause, so that a payload that is too
+// large or too deep is not reported as malformed; every other error means
+// the payload is not valid JSON.
+func jcsPayloadError(err error) error {
+	var le *canonical.LimitError
+	switch {
+	case errors.As(err, &le):
+		return fmt.Errorf("payload_encoding=jcs: %w", err)
+	case errors.Is(err, canonical.ErrNotIJSON):
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+	return fmt.Errorf("payload_encoding=jcs but payload is not valid JSON")
+}
+
+// VerifyPayloadHashJCSReader is VerifyPayloadHash for a jcs payload read from
+// r with ComputePayloadHashJCSReader.
+func VerifyPayloadHashJCSReader(envelope Envelope, r io.Reader) error {
+	if envelope.PayloadHash == "" {
+		return fmt.Errorf("missing payload_hash")
+	}
+	if envelope.PayloadEncoding != V1PayloadEncodingJCS {
+		return fmt.Errorf("unsupported payload_encoding for streaming: %s", envelope.PayloadEncoding)
+	}
+	if hasProjection(envelope) {
+		return fmt.Errorf("payload_include and payload_exclude are not supported for streaming")
+	}
+	hash, n, err := ComputePayloadHashJCSReader(r)
+	if err != nil {
+		return err
+	}
+	if s := envelope.PayloadSize; s != nil && *s != n {
+		return fmt.Errorf("size mismatch (expected %d, got %d)", *s, n)
+	}
+	if hash != envelope.PayloadHash {
+		return fmt.Errorf("payload hash mismatch")
+	}
+	return nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// errReader remembers the first error other than io.EOF that r returns, so
+// that a failed read is not mistaken for invalid input.
+type errReader struct {
+	r   io.Reader
+	err error
+}
+
+func (e *errReader) Read(p []byte) (int, error) {
+	n, err := e.r.Read(p)
+	if err != nil && err != io.EOF && e.err == nil {
+		e.err = err
+	}
+	return n, err
+}
+
+// hashNormalizedPayload hashes payload bytes that are already normalized.
+func hashNormalizedPayload(norm []byte) string {
 	sum := sha256.Sum256(norm)
-	return base64.StdEncoding.EncodeToString(sum[:]), nil
+	return base64.StdEncoding.EncodeToString(sum[:])
 }
 
 func VerifyPayloadHash(envelope Envelope, payloadBytes []byte) error {
@@ -41,41 +166,93 @@ func VerifyPayloadHash(envelope Envelope, payloadBytes []byte) error {
 		return fmt.Errorf("missing payload_hash")
 	}
 
-	want, err := ComputePayloadHash(payloadBytes, envelope.PayloadEncoding)
+	norm, err := NormalizeEnvelopePayload(envelope, payloadBytes)
 	if err != nil {
 		return err
 	}
-	if envelope.PayloadHash != want {
+	return checkNormalizedPayload(envelope, norm)
+}
+
+// checkNormalizedPayload compares normalized payload bytes with payload_size
+// (when present) and payload_hash. The size is checked first, so truncated
+// or padded payloads are reported as such without being hashed.
+func checkNormalizedPayload(envelope Envelope, norm []byte) error {
+	if s := envelope.PayloadSize; s != nil && *s != int64(len(norm)) {
+		return fmt.Errorf("size mismatch (expected %d, got %d)", *s, len(norm))
+	}
+	if hashNormalizedPayload(norm) != envelope.PayloadHash {
 		return fmt.Errorf("payload hash mismatch")
 	}
 	return nil
 }
 
 func VerifyEd25519(envelope Envelope, pub ed25519.PublicKey) error {
-	if err := ValidateEnvelopeV1(envelope); err != nil {
+	if err := ValidateEnvelope(envelope); err != nil {
 		return err
 	}
 	if err := ValidateEnvelopeV1ForVerify(envelope); err != nil {
 		return err
 	}
 
-	s := envelope.Sig
-	sig, err := base64.StdEncoding.DecodeString(*s)
+	b, err := json.Marshal(unsignedEnvelope(envelope))
 	if err != nil {
-		return fmt.Errorf("invalid sig (base64 decode failed)")
+		return err
 	}
-	if len(sig) != ed25519.SignatureSize {
-		return fmt.Errorf("invalid sig size")
+	return verifyEd25519Message(b, *envelope.Sig, pub)
+}
+
+// VerifyEd25519JSON parses a signed envelope with ParseEnvelopeStrict and
+// verifies the signature against the received JSON object itself (with
+// "sig", "signatures" and "payload" removed), instead of a re-marshaled
+// Envelope.
+func VerifyEd25519JSON(envelopeJSON []byte, pub ed25519.PublicKey) (Envelope, error) {
+	return VerifyEd25519JSONWithLimits(envelopeJSON, pub, DefaultEnvelopeLimits)
+}
+
+// VerifyEd25519JSONWithLimits is VerifyEd25519JSON with explicit limits for
+// ParseEnvelopeStrictWithLimits.
+func VerifyEd25519JSONWithLimits(envelopeJSON []byte, pub ed25519.PublicKey, l canonical.Limits) (Envelope, error) {
+	envelope, err := ParseEnvelopeStrictWithLimits(envelopeJSON, l)
+	if err != nil {
+		return Envelope{}, err
+	}
+	if err := ValidateEnvelope(envelope); err != nil {
+		return envelope, err
+	}
+	if err := ValidateEnvelopeV1ForVerify(envelope); err != nil {
+		return envelope, err
 	}
 
-	unsigned := envelope
-	unsigned.Sig = nil
+	b, err := unsignedJSONFromReceived(envelopeJSON)
+	if err != nil {
+		return envelope, err
+	}
+	return envelope, verifyEd25519Message(b, *envelope.Sig, pub)
+}
 
-	b, err := json.Marshal(unsigned)
+// unsignedJSONFromReceived drops the members that are not signed from a
+// received envelope object, keeping every other member exactly as received.
+func unsignedJSONFromReceived(envelopeJSON []byte) ([]byte, error) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(envelopeJSON, &obj); err != nil {
+		return nil, err
+	}
+	delete(obj, "sig")
+	delete(obj, "signatures")
+	delete(obj, "payload")
+	return json.Marshal(obj)
+}
+
+func verifyEd25519Message(unsignedJSON []byte, sigB64 string, pub ed25519.PublicKey) error {
+	sig, err := base64.StdEncoding.DecodeString(sigB64)
 	if err != nil {
-		return err
+		return fmt.Errorf("invalid sig (base64 decode failed)")
 	}
-	msg, err := canonical.Canonicalize(b)
+	if len(sig) != ed25519.SignatureSize {
+		return fmt.Errorf("invalid sig size")
+	}
+
+	msg, err := canonical.Canonicalize(unsignedJSON)
 	if err != nil {
 		return err
 	}
@@ -3,9 +3,11 @@ package crypto
 import (
 	"crypto/ed25519"
 	"crypto/x509"
+	"encoding/json"
 	"encoding/pem"
 	"fmt"
 	"os"
+	"path/filepath"
 )
 
 // LoadEd25519PrivateKey loads an Ed25519 private key from a PEM file.
@@ -57,3 +59,38 @@ func LoadEd25519PublicKey(path string) (ed25519.PublicKey, error) {
 	}
 	return pub, nil
 }
+
+// LoadEd25519TrustStore loads a set of Ed25519 public keys indexed by kid.
+// The trust store is a JSON object mapping each kid to a public key PEM
+// file; relative paths are resolved against the trust store's directory.
+func LoadEd25519TrustStore(path string) (map[string]ed25519.PublicKey, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries map[string]string
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return nil, fmt.Errorf("invalid trust store: %w", err)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("invalid trust store: no keys")
+	}
+
+	dir := filepath.Dir(path)
+	keys := make(map[string]ed25519.PublicKey, len(entries))
+	for kid, keyPath := range entries {
+		if kid == "" {
+			return nil, fmt.Errorf("invalid trust store: empty kid")
+		}
+		if !filepath.IsAbs(keyPath) {
+			keyPath = filepath.Join(dir, keyPath)
+		}
+		pub, err := LoadEd25519PublicKey(keyPath)
+		if err != nil {
+			return nil, fmt.Errorf("trust store kid %s: %w", kid, err)
+		}
+		keys[kid] = pub
+	}
+	return keys, nil
+}
@@ -101,3 +101,40 @@ func TestLoadEd25519PublicKey_RejectsNonEd25519(t *testing.T) {
 		t.Fatalf("want error, got nil")
 	}
 }
+
+func TestLoadEd25519TrustStore_RelativePaths_OK(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPath := writeTempPEM(t, "PUBLIC KEY", der)
+
+	dir := filepath.Dir(keyPath)
+	storePath := filepath.Join(dir, "trust.json")
+	if err := os.WriteFile(storePath, []byte(`{"officer-a":"key.pem"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := LoadEd25519TrustStore(storePath)
+	if err != nil {
+		t.Fatalf("LoadEd25519TrustStore: %v", err)
+	}
+	if !keys["officer-a"].Equal(pub) {
+		t.Fatalf("unexpected key for officer-a")
+	}
+}
+
+func TestLoadEd25519TrustStore_MissingKeyFile_Fail(t *testing.T) {
+	storePath := filepath.Join(t.TempDir(), "trust.json")
+	if err := os.WriteFile(storePath, []byte(`{"officer-a":"missing.pem"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadEd25519TrustStore(storePath); err == nil {
+		t.Fatalf("want error, got nil")
+	}
+}
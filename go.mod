@@ -2,8 +2,12 @@ module github.com/na0h/veriseal
 
 go 1.25.5
 
-// no external deps
-
 require github.com/gowebpki/jcs v1.0.1
 
-require github.com/google/uuid v1.6.0 // indirect
+require (
+	github.com/fxamacker/cbor/v2 v2.9.0
+	github.com/google/uuid v1.6.0
+	github.com/x448/float16 v0.8.4
+	golang.org/x/text v0.40.0
+	gopkg.in/yaml.v3 v3.0.1
+)